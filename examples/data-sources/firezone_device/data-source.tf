@@ -0,0 +1,16 @@
+data "firezone_device" "by_id" {
+  id = "f3b0c3a4-4d6c-4a0e-9f1f-2c7d9d3b5e71"
+}
+
+data "firezone_device" "by_public_key" {
+  public_key = "kOVg6lh7OTyVdGjmQ3kGvK1B+nD5aVt7FbT2wB1VX0Y="
+}
+
+data "firezone_device" "by_name" {
+  user_id = firezone_user.user.id
+  name    = "laptop"
+}
+
+output "laptop_ipv4" {
+  value = data.firezone_device.by_name.ipv4
+}
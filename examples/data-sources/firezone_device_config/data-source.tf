@@ -0,0 +1,17 @@
+resource "firezone_wireguard_keypair" "laptop" {}
+
+resource "firezone_device" "laptop" {
+  user_id    = firezone_user.user.id
+  name       = "laptop"
+  public_key = firezone_wireguard_keypair.laptop.public_key
+}
+
+data "firezone_device_config" "laptop" {
+  device_id   = firezone_device.laptop.id
+  private_key = firezone_wireguard_keypair.laptop.private_key
+}
+
+resource "local_sensitive_file" "laptop" {
+  content  = data.firezone_device_config.laptop.config
+  filename = "${path.module}/laptop.conf"
+}
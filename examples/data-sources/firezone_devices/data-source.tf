@@ -0,0 +1,12 @@
+data "firezone_devices" "office" {
+  cidr = "10.3.2.0/25"
+}
+
+data "firezone_devices" "laptops" {
+  user_id    = firezone_user.user.id
+  name_regex = "^laptop-"
+}
+
+output "office_device_ips" {
+  value = data.firezone_devices.office.devices[*].ipv4
+}
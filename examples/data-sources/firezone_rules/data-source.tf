@@ -0,0 +1,13 @@
+data "firezone_rules" "user" {
+  user_id = firezone_user.user.id
+}
+
+# Rules affecting traffic to the office network.
+data "firezone_rules" "office" {
+  destination = "10.10.0.0/16"
+  action      = "accept"
+}
+
+output "office_rule_ids" {
+  value = data.firezone_rules.office.rules[*].id
+}
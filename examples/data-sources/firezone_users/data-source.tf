@@ -0,0 +1,12 @@
+data "firezone_users" "admins" {
+  role     = "admin"
+  disabled = false
+}
+
+data "firezone_users" "company" {
+  email_glob = "*@example.com"
+}
+
+output "admin_emails" {
+  value = data.firezone_users.admins.users[*].email
+}
@@ -1,4 +1,12 @@
 provider "firezone" {
-  endpoint = "https://api.firezone.io/v1"
+  endpoint = "https://firezone.example.com"
   api_key  = var.firezone_api_key
+
+  # Retry transient API failures with exponential backoff.
+  max_retries    = 5
+  retry_wait_min = "1s"
+  retry_wait_max = "30s"
+
+  # Trust a private CA instead of the system roots.
+  ca_cert_file = "/etc/ssl/certs/firezone-ca.pem"
 }
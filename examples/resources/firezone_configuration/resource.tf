@@ -0,0 +1,13 @@
+resource "firezone_configuration" "this" {
+  default_client_allowed_ips          = ["10.0.0.0/8", "192.168.0.0/16"]
+  default_client_dns                  = ["10.0.0.53"]
+  default_client_endpoint             = "vpn.example.com:51820"
+  default_client_mtu                  = 1280
+  default_client_persistent_keepalive = 25
+  local_auth_enabled                  = false
+  vpn_session_duration                = 86400
+
+  logo = {
+    url = "https://example.com/logo.png"
+  }
+}
@@ -0,0 +1,5 @@
+# Devices can be imported by id, by public key, or by the email or id of
+# their user and their name separated by a slash.
+terraform import firezone_device.device 3f0c9a1d-8e2b-4c7a-b5d6-1a9e4f2c7b30
+terraform import firezone_device.device 'kOVg6lh7OTyVdGjmQ3kGvK1B+nD5aVt7FbT2wB1VX0Y='
+terraform import firezone_device.device root@example.com/laptop
@@ -3,4 +3,14 @@ resource "firezone_device" "dev1" {
   name        = "dev1"
   description = "dev1"
   public_key  = random_string.public_key.result
-}
\ No newline at end of file
+}
+
+resource "firezone_device" "split_tunnel" {
+  user_id                 = firezone_user.user.id
+  name                    = "split-tunnel"
+  public_key              = random_string.split_tunnel_public_key.result
+  use_default_allowed_ips = false
+  allowed_ips             = ["10.0.0.0/8", "192.168.0.0/16"]
+  use_default_dns         = false
+  dns                     = ["10.0.0.53", "1.1.1.1"]
+}
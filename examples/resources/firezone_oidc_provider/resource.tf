@@ -0,0 +1,8 @@
+resource "firezone_oidc_provider" "okta" {
+  id                     = "okta"
+  label                  = "Okta"
+  client_id              = var.okta_client_id
+  client_secret          = var.okta_client_secret
+  discovery_document_uri = "https://example.okta.com/.well-known/openid-configuration"
+  auto_create_users      = true
+}
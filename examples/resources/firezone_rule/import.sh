@@ -0,0 +1,8 @@
+# Rules can be imported by id or by
+# <user>/<action>/<destination>/<port_type>/<port_range>, where the user is
+# an email or id and empty for rules applying to all users. The port type
+# and range are empty for rules matching all ports.
+terraform import firezone_rule.rule 9d2e6b1a-3c4f-4e8a-a7b0-5f1c2d3e4a60
+terraform import firezone_rule.rule root@example.com/accept/10.0.0.0/8/tcp/80-443
+terraform import firezone_rule.rule /drop/172.16.0.0/12/tcp/22
+terraform import firezone_rule.rule /drop/10.0.0.0/8//
@@ -1,22 +1,27 @@
-resource "firezone_rule" "allow_all" {
+resource "firezone_rule" "allow_web" {
   action      = "accept"
-  destination = "0.0.0.0/0"
+  destination = "10.0.0.0/16"
   port_range  = "80 - 443"
   port_type   = "tcp"
 }
 
 resource "firezone_rule" "allow_https" {
   action      = "accept"
-  destination = "0.0.0.0/0"
-  port_range  = "1 - 443"
+  destination = "10.1.0.0/16"
+  port_range  = "443"
   port_type   = "tcp"
   user_id     = firezone_user.user.id
 }
 
-resource "firezone_rule" "allow_https" {
+resource "firezone_rule" "allow_ssh" {
   action      = "accept"
-  destination = "0.0.0.0/0"
-  port_range  = "1 - 443"
+  destination = "10.1.0.10"
+  port_range  = "22"
   port_type   = "tcp"
   user_id     = data.firezone_user.user.id
-}
\ No newline at end of file
+}
+
+resource "firezone_rule" "drop_private" {
+  action      = "drop"
+  destination = "10.0.0.0/8"
+}
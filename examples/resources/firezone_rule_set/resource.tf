@@ -0,0 +1,27 @@
+resource "firezone_rule_set" "developers" {
+  user_id = firezone_user.user.id
+
+  rule {
+    action      = "accept"
+    destination = "10.0.0.0/16"
+    port_type   = "tcp"
+    port_range  = "80-443"
+  }
+
+  rule {
+    action      = "accept"
+    destination = "10.0.1.10"
+    port_type   = "tcp"
+    port_range  = "22"
+  }
+}
+
+# Owns all global rules, rules created outside of Terraform are deleted.
+resource "firezone_rule_set" "global" {
+  exclusive = true
+
+  rule {
+    action      = "drop"
+    destination = "10.0.0.0/8"
+  }
+}
@@ -0,0 +1,6 @@
+resource "firezone_saml_provider" "okta" {
+  id                = "okta"
+  label             = "Okta"
+  metadata          = file("${path.module}/okta-metadata.xml")
+  auto_create_users = true
+}
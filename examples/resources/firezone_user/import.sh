@@ -0,0 +1,3 @@
+# Users can be imported by id or by email.
+terraform import firezone_user.user 7b4f5c2e-4a8e-4d3b-9f0a-2e6c1d8b5a90
+terraform import firezone_user.user root@example.com
@@ -1,4 +1,26 @@
 resource "firezone_user" "user" {
   email = "root@example.com"
   role  = "admin"
-}
\ No newline at end of file
+}
+
+# Offboarded users keep their devices but can no longer connect.
+resource "firezone_user" "contractor" {
+  email    = "contractor@example.com"
+  role     = "unprivileged"
+  disabled = true
+}
+
+# Break-glass admin signing in with email and password. Bump
+# password_version to set the password again, e.g. after rotating it.
+variable "break_glass_password" {
+  type      = string
+  sensitive = true
+}
+
+resource "firezone_user" "break_glass" {
+  email                 = "break-glass@example.com"
+  role                  = "admin"
+  password              = var.break_glass_password
+  password_confirmation = var.break_glass_password
+  password_version      = "2023-06-01"
+}
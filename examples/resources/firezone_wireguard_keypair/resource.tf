@@ -0,0 +1,12 @@
+resource "firezone_wireguard_keypair" "laptop" {
+  # Change any keeper value to rotate the keypair.
+  keepers = {
+    rotated_at = "2023-06-01"
+  }
+}
+
+resource "firezone_device" "laptop" {
+  user_id    = firezone_user.user.id
+  name       = "laptop"
+  public_key = firezone_wireguard_keypair.laptop.public_key
+}
@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// apiRequest sends a request to a Firezone API endpoint which the Firezone
+// client does not cover, or covers incompletely. body is sent as JSON and
+// the "data" member of the response is decoded into out when given.
+// Unsuccessful responses return the same errors as the client, so
+// statusCode works for both.
+func apiRequest(client *fz.Client, method string, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+
+	if body != nil {
+		rb, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(rb)
+	}
+
+	req, err := http.NewRequest(method, client.HostURL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.ApiKey))
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	rb, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("status: %d, body: %s", res.StatusCode, rb)
+	}
+
+	if out == nil || len(rb) == 0 {
+		return nil
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+
+	if err := json.Unmarshal(rb, &envelope); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(envelope.Data, out)
+}
@@ -0,0 +1,499 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &ConfigurationResource{}
+var _ resource.ResourceWithImportState = &ConfigurationResource{}
+
+func NewConfigurationResource() resource.Resource {
+	return &ConfigurationResource{}
+}
+
+// ConfigurationResource defines the resource implementation.
+type ConfigurationResource struct {
+	client *fz.Client
+}
+
+// ConfigurationResourceModel describes the resource data model.
+type ConfigurationResourceModel struct {
+	Id                                   types.String `tfsdk:"id"`
+	AllowUnprivilegedDeviceConfiguration types.Bool   `tfsdk:"allow_unprivileged_device_configuration"`
+	AllowUnprivilegedDeviceManagement    types.Bool   `tfsdk:"allow_unprivileged_device_management"`
+	DefaultClientAllowedIPs              types.Set    `tfsdk:"default_client_allowed_ips"`
+	DefaultClientDNS                     types.List   `tfsdk:"default_client_dns"`
+	DefaultClientEndpoint                types.String `tfsdk:"default_client_endpoint"`
+	DefaultClientMTU                     types.Int64  `tfsdk:"default_client_mtu"`
+	DefaultClientPersistentKeepalive     types.Int64  `tfsdk:"default_client_persistent_keepalive"`
+	DisableVPNOnOIDCError                types.Bool   `tfsdk:"disable_vpn_on_oidc_error"`
+	LocalAuthEnabled                     types.Bool   `tfsdk:"local_auth_enabled"`
+	Logo                                 types.Object `tfsdk:"logo"`
+	VPNSessionDuration                   types.Int64  `tfsdk:"vpn_session_duration"`
+}
+
+// ConfigurationLogoModel describes the logo attribute data model.
+type ConfigurationLogoModel struct {
+	URL  types.String `tfsdk:"url"`
+	Data types.String `tfsdk:"data"`
+	Type types.String `tfsdk:"type"`
+}
+
+var configurationLogoAttrTypes = map[string]attr.Type{
+	"url":  types.StringType,
+	"data": types.StringType,
+	"type": types.StringType,
+}
+
+// firezoneConfiguration is the Firezone configuration object. The Firezone
+// client drops the logo and cannot send partial updates, so the provider
+// reads and updates the configuration itself.
+type firezoneConfiguration struct {
+	ID                                   string            `json:"id"`
+	AllowUnprivilegedDeviceConfiguration bool              `json:"allow_unprivileged_device_configuration"`
+	AllowUnprivilegedDeviceManagement    bool              `json:"allow_unprivileged_device_management"`
+	DefaultClientAllowedIPs              []string          `json:"default_client_allowed_ips"`
+	DefaultClientDNS                     []string          `json:"default_client_dns"`
+	DefaultClientEndpoint                string            `json:"default_client_endpoint"`
+	DefaultClientMTU                     int               `json:"default_client_mtu"`
+	DefaultClientPersistentKeepalive     int               `json:"default_client_persistent_keepalive"`
+	DisableVPNOnOIDCError                bool              `json:"disable_vpn_on_oidc_error"`
+	LocalAuthEnabled                     bool              `json:"local_auth_enabled"`
+	Logo                                 *firezoneLogo     `json:"logo"`
+	OpenIDConnectProviders               []json.RawMessage `json:"openid_connect_providers"`
+	SAMLIdentityProviders                []json.RawMessage `json:"saml_identity_providers"`
+	VPNSessionDuration                   int               `json:"vpn_session_duration"`
+}
+
+// firezoneLogo is the logo shown on the Firezone sign in page, either
+// loaded from url or uploaded as base64 encoded data of a MIME type.
+type firezoneLogo struct {
+	URL  *string `json:"url"`
+	Data *string `json:"data"`
+	Type *string `json:"type"`
+}
+
+// getConfiguration reads the Firezone configuration.
+func getConfiguration(client *fz.Client) (*firezoneConfiguration, error) {
+	var configuration firezoneConfiguration
+
+	if err := apiRequest(client, http.MethodGet, "/v0/configuration", nil, &configuration); err != nil {
+		return nil, err
+	}
+
+	return &configuration, nil
+}
+
+// updateConfiguration changes the given configuration fields, leaving all
+// others as they are.
+func updateConfiguration(client *fz.Client, params map[string]interface{}) (*firezoneConfiguration, error) {
+	var configuration firezoneConfiguration
+
+	body := map[string]interface{}{"configuration": params}
+
+	if err := apiRequest(client, http.MethodPatch, "/v0/configuration", body, &configuration); err != nil {
+		return nil, err
+	}
+
+	return &configuration, nil
+}
+
+func (r *ConfigurationResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_configuration"
+}
+
+func (r *ConfigurationResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Server-wide Firezone configuration. There is exactly one configuration, so only declare " +
+			"this resource once. Settings which are not set are left as they are. Destroying the resource only removes " +
+			"it from the Terraform state, the configuration is not changed.",
+
+		Attributes: map[string]schema.Attribute{
+			"allow_unprivileged_device_configuration": schema.BoolAttribute{
+				MarkdownDescription: "Allow unprivileged users to change the configuration of their devices",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"allow_unprivileged_device_management": schema.BoolAttribute{
+				MarkdownDescription: "Allow unprivileged users to create and delete their devices",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"default_client_allowed_ips": schema.SetAttribute{
+				MarkdownDescription: "Default device allowed ips in CIDR notation",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(isCIDR()),
+				},
+			},
+			"default_client_dns": schema.ListAttribute{
+				MarkdownDescription: "Default device DNS servers in order of preference",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(isIPAddress()),
+				},
+			},
+			"default_client_endpoint": schema.StringAttribute{
+				MarkdownDescription: "Default endpoint devices connect to, as `host` or `host:port`",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"default_client_mtu": schema.Int64Attribute{
+				MarkdownDescription: "Default device MTU",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.Int64{
+					int64validator.Between(576, 1500),
+				},
+			},
+			"default_client_persistent_keepalive": schema.Int64Attribute{
+				MarkdownDescription: "Default device persistent keepalive in seconds, 0 disables it",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.Int64{
+					int64validator.Between(0, 120),
+				},
+			},
+			"disable_vpn_on_oidc_error": schema.BoolAttribute{
+				MarkdownDescription: "Disable the VPN connection of a user when refreshing their OIDC token fails",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"local_auth_enabled": schema.BoolAttribute{
+				MarkdownDescription: "Allow users to sign in with email and password",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"logo": schema.SingleNestedAttribute{
+				MarkdownDescription: "Logo shown on the sign in page, either `url` or `data` and `type`",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.UseStateForUnknown(),
+				},
+				Attributes: map[string]schema.Attribute{
+					"url": schema.StringAttribute{
+						MarkdownDescription: "Logo URL",
+						Optional:            true,
+						Validators: []validator.String{
+							stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("data")),
+						},
+					},
+					"data": schema.StringAttribute{
+						MarkdownDescription: "Base64 encoded logo image",
+						Optional:            true,
+						Validators: []validator.String{
+							stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("type")),
+						},
+					},
+					"type": schema.StringAttribute{
+						MarkdownDescription: "MIME type of `data`, e.g. `image/png`",
+						Optional:            true,
+						Validators: []validator.String{
+							stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("data")),
+						},
+					},
+				},
+			},
+			"vpn_session_duration": schema.Int64Attribute{
+				MarkdownDescription: "Time in seconds after which users have to sign in again to keep their VPN sessions, 0 never expires them",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Configuration identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *ConfigurationResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fz.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
+
+func (r *ConfigurationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *ConfigurationResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The configuration always exists, so creating the resource adopts it.
+	resp.Diagnostics.Append(r.apply(ctx, data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Write logs using the tflog package
+	// Documentation: https://terraform.io/plugin/log
+	tflog.Trace(ctx, "created a resource")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *ConfigurationResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *ConfigurationResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	configuration, err := getConfiguration(r.client)
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read configuration, got error: %s", err))
+		return
+	}
+
+	resp.Diagnostics.Append(data.fromConfiguration(ctx, configuration)...)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *ConfigurationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *ConfigurationResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *ConfigurationResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// The configuration cannot be deleted and there is no sensible state to
+	// reset it to, so it is only removed from the Terraform state.
+	tflog.Info(ctx, "firezone_configuration removed from state, the Firezone configuration is left unchanged")
+}
+
+func (r *ConfigurationResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	// Any ID imports the configuration, Read replaces it with the real one.
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// apply sends the configured settings to Firezone and copies the resulting
+// configuration into the model.
+func (r *ConfigurationResource) apply(ctx context.Context, data *ConfigurationResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	params, d := data.toParams(ctx)
+	diags.Append(d...)
+
+	if diags.HasError() {
+		return diags
+	}
+
+	configuration, err := updateConfiguration(r.client, params)
+
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to update configuration, got error: %s", err))
+		return diags
+	}
+
+	diags.Append(data.fromConfiguration(ctx, configuration)...)
+
+	return diags
+}
+
+// toParams converts the settings known in the model into the fields of a
+// configuration update. Unknown settings are not configured and therefore
+// left out so Firezone keeps their current values.
+func (m *ConfigurationResourceModel) toParams(ctx context.Context) (map[string]interface{}, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	params := map[string]interface{}{}
+
+	if isKnown(m.AllowUnprivilegedDeviceConfiguration) {
+		params["allow_unprivileged_device_configuration"] = m.AllowUnprivilegedDeviceConfiguration.ValueBool()
+	}
+	if isKnown(m.AllowUnprivilegedDeviceManagement) {
+		params["allow_unprivileged_device_management"] = m.AllowUnprivilegedDeviceManagement.ValueBool()
+	}
+	if isKnown(m.DefaultClientAllowedIPs) {
+		var allowedIPs []string
+		diags.Append(m.DefaultClientAllowedIPs.ElementsAs(ctx, &allowedIPs, false)...)
+		// Keep allowed ips in a stable order; the set itself is unordered.
+		sort.Strings(allowedIPs)
+		params["default_client_allowed_ips"] = nonNilStrings(allowedIPs)
+	}
+	if isKnown(m.DefaultClientDNS) {
+		var dns []string
+		diags.Append(m.DefaultClientDNS.ElementsAs(ctx, &dns, false)...)
+		params["default_client_dns"] = nonNilStrings(dns)
+	}
+	if isKnown(m.DefaultClientEndpoint) {
+		params["default_client_endpoint"] = m.DefaultClientEndpoint.ValueString()
+	}
+	if isKnown(m.DefaultClientMTU) {
+		params["default_client_mtu"] = m.DefaultClientMTU.ValueInt64()
+	}
+	if isKnown(m.DefaultClientPersistentKeepalive) {
+		params["default_client_persistent_keepalive"] = m.DefaultClientPersistentKeepalive.ValueInt64()
+	}
+	if isKnown(m.DisableVPNOnOIDCError) {
+		params["disable_vpn_on_oidc_error"] = m.DisableVPNOnOIDCError.ValueBool()
+	}
+	if isKnown(m.LocalAuthEnabled) {
+		params["local_auth_enabled"] = m.LocalAuthEnabled.ValueBool()
+	}
+	if isKnown(m.Logo) {
+		var logo ConfigurationLogoModel
+		diags.Append(m.Logo.As(ctx, &logo, basetypes.ObjectAsOptions{})...)
+		params["logo"] = firezoneLogo{
+			URL:  logo.URL.ValueStringPointer(),
+			Data: logo.Data.ValueStringPointer(),
+			Type: logo.Type.ValueStringPointer(),
+		}
+	}
+	if isKnown(m.VPNSessionDuration) {
+		params["vpn_session_duration"] = m.VPNSessionDuration.ValueInt64()
+	}
+
+	return params, diags
+}
+
+// fromConfiguration copies the Firezone configuration into the model.
+func (m *ConfigurationResourceModel) fromConfiguration(ctx context.Context, configuration *firezoneConfiguration) diag.Diagnostics {
+	var diags, d diag.Diagnostics
+
+	m.Id = types.StringValue(configuration.ID)
+	m.AllowUnprivilegedDeviceConfiguration = types.BoolValue(configuration.AllowUnprivilegedDeviceConfiguration)
+	m.AllowUnprivilegedDeviceManagement = types.BoolValue(configuration.AllowUnprivilegedDeviceManagement)
+	m.DefaultClientEndpoint = types.StringValue(configuration.DefaultClientEndpoint)
+	m.DefaultClientMTU = types.Int64Value(int64(configuration.DefaultClientMTU))
+	m.DefaultClientPersistentKeepalive = types.Int64Value(int64(configuration.DefaultClientPersistentKeepalive))
+	m.DisableVPNOnOIDCError = types.BoolValue(configuration.DisableVPNOnOIDCError)
+	m.LocalAuthEnabled = types.BoolValue(configuration.LocalAuthEnabled)
+	m.VPNSessionDuration = types.Int64Value(int64(configuration.VPNSessionDuration))
+
+	m.DefaultClientAllowedIPs, d = types.SetValueFrom(ctx, types.StringType, nonNilStrings(configuration.DefaultClientAllowedIPs))
+	diags.Append(d...)
+	m.DefaultClientDNS, d = types.ListValueFrom(ctx, types.StringType, nonNilStrings(configuration.DefaultClientDNS))
+	diags.Append(d...)
+
+	if configuration.Logo == nil {
+		m.Logo = types.ObjectNull(configurationLogoAttrTypes)
+	} else {
+		m.Logo, d = types.ObjectValueFrom(ctx, configurationLogoAttrTypes, ConfigurationLogoModel{
+			URL:  types.StringPointerValue(configuration.Logo.URL),
+			Data: types.StringPointerValue(configuration.Logo.Data),
+			Type: types.StringPointerValue(configuration.Logo.Type),
+		})
+		diags.Append(d...)
+	}
+
+	return diags
+}
+
+// isKnown reports whether value is set in the configuration or plan.
+func isKnown(value attr.Value) bool {
+	return !value.IsNull() && !value.IsUnknown()
+}
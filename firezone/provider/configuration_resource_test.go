@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccConfigurationResource(t *testing.T) {
+	t.Cleanup(testAccFirezone.resetConfiguration)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckConfigurationResourceDestroy(1380),
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: providerConfig + testAccConfigurationResourceConfig(1420, `url = "https://example.com/logo.png"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_configuration.test", "default_client_mtu", "1420"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "default_client_dns.#", "1"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "default_client_dns.0", "10.0.0.53"),
+					resource.TestCheckTypeSetElemAttr("firezone_configuration.test", "default_client_allowed_ips.*", "10.0.0.0/8"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "default_client_endpoint", "vpn.test.example.com:51820"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "default_client_persistent_keepalive", "30"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "local_auth_enabled", "false"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "vpn_session_duration", "3600"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "logo.url", "https://example.com/logo.png"),
+					resource.TestCheckResourceAttrSet("firezone_configuration.test", "id"),
+					func(s *terraform.State) error {
+						if mtu := testAccFirezone.config().DefaultClientMTU; mtu != 1420 {
+							return fmt.Errorf("expected default client MTU 1420, got %d", mtu)
+						}
+						return nil
+					},
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      "firezone_configuration.test",
+				ImportState:       true,
+				ImportStateId:     "configuration",
+				ImportStateVerify: true,
+			},
+			// Update and Read testing
+			{
+				Config: providerConfig + testAccConfigurationResourceConfig(1380, `
+    data = "iVBORw0KGgo="
+    type = "image/png"
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_configuration.test", "default_client_mtu", "1380"),
+					resource.TestCheckNoResourceAttr("firezone_configuration.test", "logo.url"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "logo.data", "iVBORw0KGgo="),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "logo.type", "image/png"),
+				),
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
+
+func TestAccConfigurationResource_partial(t *testing.T) {
+	t.Cleanup(testAccFirezone.resetConfiguration)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Settings which are not configured keep their current values
+			{
+				Config: providerConfig + `
+resource "firezone_configuration" "test" {
+  allow_unprivileged_device_management = true
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_configuration.test", "allow_unprivileged_device_management", "true"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "default_client_mtu", "1280"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "default_client_dns.#", "2"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "default_client_endpoint", "vpn.example.com:51820"),
+					resource.TestCheckResourceAttr("firezone_configuration.test", "local_auth_enabled", "true"),
+					resource.TestCheckNoResourceAttr("firezone_configuration.test", "logo.url"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckConfigurationResourceDestroy checks that destroying the
+// resource left the configuration alone.
+func testAccCheckConfigurationResourceDestroy(mtu int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if actual := testAccFirezone.config().DefaultClientMTU; actual != mtu {
+			return fmt.Errorf("expected default client MTU to stay %d, got %d", mtu, actual)
+		}
+		return nil
+	}
+}
+
+func testAccConfigurationResourceConfig(mtu int, logo string) string {
+	return fmt.Sprintf(`
+resource "firezone_configuration" "test" {
+  default_client_allowed_ips          = ["10.0.0.0/8"]
+  default_client_dns                  = ["10.0.0.53"]
+  default_client_endpoint             = "vpn.test.example.com:51820"
+  default_client_mtu                  = %[1]d
+  default_client_persistent_keepalive = 30
+  local_auth_enabled                  = false
+  vpn_session_duration                = 3600
+
+  logo = {
+    %[2]s
+  }
+}
+`, mtu, logo)
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var _ basetypes.StringTypable = DestinationType{}
+var _ basetypes.StringValuableWithSemanticEquals = DestinationValue{}
+
+// DestinationType is the type of rule destinations, which are IPv4 or IPv6
+// networks in CIDR notation or single addresses.
+type DestinationType struct {
+	basetypes.StringType
+}
+
+func (t DestinationType) String() string {
+	return "DestinationType"
+}
+
+func (t DestinationType) Equal(o attr.Type) bool {
+	other, ok := o.(DestinationType)
+
+	if !ok {
+		return false
+	}
+
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t DestinationType) ValueType(ctx context.Context) attr.Value {
+	return DestinationValue{}
+}
+
+func (t DestinationType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return DestinationValue{StringValue: in}, nil
+}
+
+func (t DestinationType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stringValue, ok := attrValue.(basetypes.StringValue)
+
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	return DestinationValue{StringValue: stringValue}, nil
+}
+
+// DestinationValue is a rule destination. Destinations are equal when they
+// are the same network, e.g. 10.0.0.1 and 10.0.0.1/32 or 10.0.0.1/8 and
+// 10.0.0.0/8, so the form Firezone returns them in does not cause diffs.
+type DestinationValue struct {
+	basetypes.StringValue
+}
+
+func (v DestinationValue) Type(ctx context.Context) attr.Type {
+	return DestinationType{}
+}
+
+func (v DestinationValue) Equal(o attr.Value) bool {
+	other, ok := o.(DestinationValue)
+
+	if !ok {
+		return false
+	}
+
+	return v.StringValue.Equal(other.StringValue)
+}
+
+func (v DestinationValue) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(DestinationValue)
+
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			fmt.Sprintf("Expected value type %T, got: %T. Please report this issue to the provider developers.", v, newValuable),
+		)
+
+		return false, diags
+	}
+
+	return sameRuleDestination(v.ValueString(), newValue.ValueString()), diags
+}
+
+// DestinationStringValue returns a known destination.
+func DestinationStringValue(value string) DestinationValue {
+	return DestinationValue{StringValue: basetypes.NewStringValue(value)}
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+)
+
+func TestDestinationValueSemanticEquals(t *testing.T) {
+	equal := [][2]string{
+		{"10.0.0.0/8", "10.0.0.0/8"},
+		{"10.0.0.1/8", "10.0.0.0/8"},
+		{"10.0.0.1", "10.0.0.1/32"},
+		{"2001:db8::1", "2001:db8::1/128"},
+		{"2001:db8::1/32", "2001:db8::/32"},
+	}
+
+	for _, pair := range equal {
+		ok, diags := DestinationStringValue(pair[0]).StringSemanticEquals(context.Background(), DestinationStringValue(pair[1]))
+		if diags.HasError() {
+			t.Fatalf("%q, %q: unexpected error: %v", pair[0], pair[1], diags)
+		}
+		if !ok {
+			t.Errorf("%q, %q: expected them to be equal", pair[0], pair[1])
+		}
+	}
+
+	different := [][2]string{
+		{"10.0.0.0/8", "10.0.0.0/16"},
+		{"10.0.0.1", "10.0.0.0/24"},
+		{"10.0.0.1", "::ffff:10.0.0.1"},
+		{"not-a-network", "10.0.0.0/8"},
+	}
+
+	for _, pair := range different {
+		ok, _ := DestinationStringValue(pair[0]).StringSemanticEquals(context.Background(), DestinationStringValue(pair[1]))
+		if ok {
+			t.Errorf("%q, %q: expected them to be different", pair[0], pair[1])
+		}
+	}
+}
+
+func TestIsBroadNetwork(t *testing.T) {
+	broad := []string{"0.0.0.0/0", "0.0.0.0/1", "10.0.0.0/7", "::/0", "2000::/3"}
+
+	for _, network := range broad {
+		if !isBroadNetwork(netip.MustParsePrefix(network)) {
+			t.Errorf("%q: expected it to be broad", network)
+		}
+	}
+
+	narrow := []string{"10.0.0.0/8", "192.168.0.0/16", "10.0.0.1/32", "2001:db8::/32", "fd00::/16"}
+
+	for _, network := range narrow {
+		if isBroadNetwork(netip.MustParsePrefix(network)) {
+			t.Errorf("%q: expected it not to be broad", network)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &DeviceConfigDataSource{}
+
+// wireGuardPrivateKeyPlaceholder is written instead of the private key when
+// none is given, the same as in the configuration Firezone offers for
+// download.
+const wireGuardPrivateKeyPlaceholder = "REPLACE_ME"
+
+// defaultWireGuardPort is appended to endpoints which do not specify a port.
+const defaultWireGuardPort = "51820"
+
+func NewDeviceConfigDataSource() datasource.DataSource {
+	return &DeviceConfigDataSource{}
+}
+
+// DeviceConfigDataSource defines the data source implementation.
+type DeviceConfigDataSource struct {
+	client *fz.Client
+}
+
+// DeviceConfigDataSourceModel describes the data source data model.
+type DeviceConfigDataSourceModel struct {
+	Id         types.String `tfsdk:"id"`
+	DeviceId   types.String `tfsdk:"device_id"`
+	PrivateKey types.String `tfsdk:"private_key"`
+	Config     types.String `tfsdk:"config"`
+	ConfigJSON types.String `tfsdk:"config_json"`
+}
+
+// wireGuardConfig is a device's client configuration with every
+// use_default_* flag resolved against the server configuration.
+type wireGuardConfig struct {
+	Interface wireGuardInterface `json:"interface"`
+	Peer      wireGuardPeer      `json:"peer"`
+}
+
+type wireGuardInterface struct {
+	PrivateKey string   `json:"private_key"`
+	Address    []string `json:"address"`
+	MTU        int      `json:"mtu,omitempty"`
+	DNS        []string `json:"dns"`
+}
+
+type wireGuardPeer struct {
+	PublicKey           string   `json:"public_key"`
+	PresharedKey        string   `json:"preshared_key,omitempty"`
+	AllowedIPs          []string `json:"allowed_ips"`
+	Endpoint            string   `json:"endpoint"`
+	PersistentKeepalive int      `json:"persistent_keepalive,omitempty"`
+}
+
+func (d *DeviceConfigDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_device_config"
+}
+
+func (d *DeviceConfigDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Device config data source. Renders the wg-quick client configuration of a device, " +
+			"resolving every `use_default_*` flag against the server defaults.",
+
+		Attributes: map[string]schema.Attribute{
+			"device_id": schema.StringAttribute{
+				MarkdownDescription: "Device identifier",
+				Required:            true,
+			},
+			"private_key": schema.StringAttribute{
+				MarkdownDescription: "Device private key, e.g. from `firezone_wireguard_keypair`. " +
+					"When omitted the configuration contains `" + wireGuardPrivateKeyPlaceholder + "` instead.",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"config": schema.StringAttribute{
+				MarkdownDescription: "wg-quick configuration in INI format",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"config_json": schema.StringAttribute{
+				MarkdownDescription: "Configuration as a JSON document with `interface` and `peer` objects",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Device identifier",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *DeviceConfigDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fz.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *DeviceConfigDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data DeviceConfigDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	device, err := d.client.GetDevice(data.DeviceId.ValueString())
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read device, got error: %s", err))
+		return
+	}
+
+	configuration, err := d.client.GetConfiguration()
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read configuration, got error: %s", err))
+		return
+	}
+
+	privateKey := wireGuardPrivateKeyPlaceholder
+
+	if !data.PrivateKey.IsNull() {
+		privateKey = data.PrivateKey.ValueString()
+
+		publicKey, err := wireGuardPublicKey(privateKey)
+
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("private_key"), "Invalid Private Key", err.Error())
+			return
+		}
+
+		if publicKey != device.PublicKey {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("private_key"),
+				"Private Key Mismatch",
+				fmt.Sprintf("The private key belongs to public key %s, but device %s uses public key %s.", publicKey, device.ID, device.PublicKey),
+			)
+			return
+		}
+	}
+
+	config := newWireGuardConfig(device, configuration, privateKey)
+
+	configJSON, err := json.MarshalIndent(config, "", "  ")
+
+	if err != nil {
+		resp.Diagnostics.AddError("Encoding Error", fmt.Sprintf("Unable to encode configuration, got error: %s", err))
+		return
+	}
+
+	data.Id = types.StringValue(device.ID)
+	data.Config = types.StringValue(config.String())
+	data.ConfigJSON = types.StringValue(string(configJSON))
+
+	// Write logs using the tflog package
+	// Documentation: https://terraform.io/plugin/log
+	tflog.Trace(ctx, "read a data source")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// newWireGuardConfig resolves the device settings against the server
+// configuration.
+func newWireGuardConfig(device *fz.Device, configuration *fz.Configuration, privateKey string) wireGuardConfig {
+	config := wireGuardConfig{
+		Interface: wireGuardInterface{
+			PrivateKey: privateKey,
+			Address:    []string{},
+			MTU:        device.MTU,
+			DNS:        nonNilStrings(device.DNS),
+		},
+		Peer: wireGuardPeer{
+			PublicKey:           device.ServerPublicKey,
+			PresharedKey:        device.PresharedKey,
+			AllowedIPs:          nonNilStrings(device.AllowedIPs),
+			Endpoint:            device.Endpoint,
+			PersistentKeepalive: device.PersistentKeepalive,
+		},
+	}
+
+	if device.IPv4 != "" {
+		config.Interface.Address = append(config.Interface.Address, device.IPv4+"/32")
+	}
+	if device.IPv6 != "" {
+		config.Interface.Address = append(config.Interface.Address, device.IPv6+"/128")
+	}
+
+	if device.UseDefaultMTU {
+		config.Interface.MTU = configuration.DefaultClientMTU
+	}
+	if device.UseDefaultDNS {
+		config.Interface.DNS = nonNilStrings(configuration.DefaultClientDNS)
+	}
+	if device.UseDefaultAllowedIPs {
+		config.Peer.AllowedIPs = nonNilStrings(configuration.DefaultClientAllowedIPs)
+	}
+	if device.UseDefaultEndpoint {
+		config.Peer.Endpoint = configuration.DefaultClientEndpoint
+	}
+	if device.UseDefaultPersistentKeepalive {
+		config.Peer.PersistentKeepalive = configuration.DefaultClientPersistentKeepalive
+	}
+
+	if config.Peer.Endpoint != "" {
+		if _, _, err := net.SplitHostPort(config.Peer.Endpoint); err != nil {
+			config.Peer.Endpoint = net.JoinHostPort(strings.Trim(config.Peer.Endpoint, "[]"), defaultWireGuardPort)
+		}
+	}
+
+	return config
+}
+
+// String renders the configuration in the INI format read by wg-quick.
+// Settings without a value are left out.
+func (c wireGuardConfig) String() string {
+	var b strings.Builder
+
+	b.WriteString("[Interface]\n")
+	fmt.Fprintf(&b, "PrivateKey = %s\n", c.Interface.PrivateKey)
+	if len(c.Interface.Address) > 0 {
+		fmt.Fprintf(&b, "Address = %s\n", strings.Join(c.Interface.Address, ", "))
+	}
+	if c.Interface.MTU > 0 {
+		fmt.Fprintf(&b, "MTU = %d\n", c.Interface.MTU)
+	}
+	if len(c.Interface.DNS) > 0 {
+		fmt.Fprintf(&b, "DNS = %s\n", strings.Join(c.Interface.DNS, ", "))
+	}
+
+	b.WriteString("\n[Peer]\n")
+	fmt.Fprintf(&b, "PublicKey = %s\n", c.Peer.PublicKey)
+	if c.Peer.PresharedKey != "" {
+		fmt.Fprintf(&b, "PresharedKey = %s\n", c.Peer.PresharedKey)
+	}
+	if len(c.Peer.AllowedIPs) > 0 {
+		fmt.Fprintf(&b, "AllowedIPs = %s\n", strings.Join(c.Peer.AllowedIPs, ", "))
+	}
+	if c.Peer.Endpoint != "" {
+		fmt.Fprintf(&b, "Endpoint = %s\n", c.Peer.Endpoint)
+	}
+	if c.Peer.PersistentKeepalive > 0 {
+		fmt.Fprintf(&b, "PersistentKeepalive = %d\n", c.Peer.PersistentKeepalive)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDeviceConfigDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: providerConfig + testAccDeviceConfigDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.firezone_device_config.test", "id", "firezone_device.test", "id"),
+					resource.TestMatchResourceAttr("data.firezone_device_config.test", "config", regexp.MustCompile(
+						`^\[Interface\]\n`+
+							`PrivateKey = [A-Za-z0-9+/]{43}=\n`+
+							`Address = 10\.3\.2\.\d+/32, fd00::3:2:[0-9a-f]+/128\n`+
+							`MTU = 1400\n`+
+							`DNS = 1\.1\.1\.1, 1\.0\.0\.1\n`+
+							`\n\[Peer\]\n`+
+							`PublicKey = `+regexp.QuoteMeta(fakeServerPublicKey)+`\n`+
+							`PresharedKey = [A-Za-z0-9+/]{43}=\n`+
+							`AllowedIPs = 10\.0\.0\.0/8\n`+
+							`Endpoint = vpn\.example\.com:51820\n`+
+							`PersistentKeepalive = 25\n$`,
+					)),
+					resource.TestMatchResourceAttr("data.firezone_device_config.test", "config_json", regexp.MustCompile(`"mtu": 1400`)),
+					resource.TestMatchResourceAttr("data.firezone_device_config.placeholder", "config", regexp.MustCompile(`PrivateKey = REPLACE_ME\n`)),
+				),
+			},
+			// A private key of another keypair is rejected
+			{
+				Config:      providerConfig + testAccDeviceConfigDataSourceConfig + testAccDeviceConfigDataSourceMismatchConfig,
+				ExpectError: regexp.MustCompile(`Private Key Mismatch`),
+			},
+		},
+	})
+}
+
+const testAccDeviceConfigDataSourceConfig = `
+resource "firezone_wireguard_keypair" "test" {}
+
+resource "firezone_user" "test" {
+  email = "config@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_device" "test" {
+  user_id                 = firezone_user.test.id
+  name                    = "configured"
+  public_key              = firezone_wireguard_keypair.test.public_key
+  use_default_mtu         = false
+  mtu                     = 1400
+  use_default_allowed_ips = false
+  allowed_ips             = ["10.0.0.0/8"]
+}
+
+data "firezone_device_config" "test" {
+  device_id   = firezone_device.test.id
+  private_key = firezone_wireguard_keypair.test.private_key
+}
+
+data "firezone_device_config" "placeholder" {
+  device_id = firezone_device.test.id
+}
+`
+
+const testAccDeviceConfigDataSourceMismatchConfig = `
+resource "firezone_wireguard_keypair" "other" {}
+
+data "firezone_device_config" "mismatch" {
+  device_id   = firezone_device.test.id
+  private_key = firezone_wireguard_keypair.other.private_key
+}
+`
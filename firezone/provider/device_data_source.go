@@ -0,0 +1,261 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &DeviceDataSource{}
+
+func NewDeviceDataSource() datasource.DataSource {
+	return &DeviceDataSource{}
+}
+
+// DeviceDataSource defines the data source implementation. It has the same
+// attributes as the device resource, so DeviceResourceModel is its model.
+type DeviceDataSource struct {
+	client *fz.Client
+}
+
+func (d *DeviceDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_device"
+}
+
+func (d *DeviceDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Device data source. Looks up exactly one device by `id`, by `public_key`, " +
+			"or by `user_id` and `name`.",
+
+		Attributes: map[string]schema.Attribute{
+			"endpoint": schema.StringAttribute{
+				MarkdownDescription: "Device endpoint",
+				Computed:            true,
+			},
+			"preshared_key": schema.StringAttribute{
+				MarkdownDescription: "Device preshared key",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"mtu": schema.Int64Attribute{
+				MarkdownDescription: "Device MTU",
+				Computed:            true,
+			},
+			"use_default_dns": schema.BoolAttribute{
+				MarkdownDescription: "Device use default DNS",
+				Computed:            true,
+			},
+			"use_default_endpoint": schema.BoolAttribute{
+				MarkdownDescription: "Device use default endpoint",
+				Computed:            true,
+			},
+			"use_default_mtu": schema.BoolAttribute{
+				MarkdownDescription: "Device use default MTU",
+				Computed:            true,
+			},
+			"use_default_allowed_ips": schema.BoolAttribute{
+				MarkdownDescription: "Device use default allowed ips",
+				Computed:            true,
+			},
+			"use_default_persistent_keepalive": schema.BoolAttribute{
+				MarkdownDescription: "Device use default persistent keepalive",
+				Computed:            true,
+			},
+			"persistent_keepalive": schema.Int64Attribute{
+				MarkdownDescription: "Device persistent keepalive",
+				Computed:            true,
+			},
+			"ipv6": schema.StringAttribute{
+				MarkdownDescription: "Device IPv6",
+				Computed:            true,
+			},
+			"ipv4": schema.StringAttribute{
+				MarkdownDescription: "Device IPv4",
+				Computed:            true,
+			},
+			"description": schema.StringAttribute{
+				MarkdownDescription: "Device description",
+				Computed:            true,
+			},
+			"allowed_ips": schema.SetAttribute{
+				MarkdownDescription: "Device allowed ips, used when `use_default_allowed_ips` is false",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"dns": schema.ListAttribute{
+				MarkdownDescription: "Device DNS servers, used when `use_default_dns` is false",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"public_key": schema.StringAttribute{
+				MarkdownDescription: "Device public key, to look the device up by",
+				Optional:            true,
+				Computed:            true,
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Device name, to look the device up by together with `user_id`",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("user_id")),
+				},
+			},
+			"user_id": schema.StringAttribute{
+				MarkdownDescription: "Device user id, to look the device up by together with `name`",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("name")),
+				},
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Device identifier, to look the device up by",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("public_key"), path.MatchRoot("name")),
+				},
+			},
+		},
+	}
+}
+
+func (d *DeviceDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fz.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *DeviceDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data DeviceResourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var device *fz.Device
+
+	switch {
+	case !data.Id.IsNull():
+		var err error
+		device, err = d.client.GetDevice(data.Id.ValueString())
+
+		if isNotFound(err) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("id"),
+				"Device Not Found",
+				fmt.Sprintf("No device with id %q exists.", data.Id.ValueString()),
+			)
+			return
+		}
+
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read device, got error: %s", err))
+			return
+		}
+
+	case !data.PublicKey.IsNull():
+		publicKey := data.PublicKey.ValueString()
+
+		device = d.findDevice(resp, path.Root("public_key"), fmt.Sprintf("with public key %q", publicKey), func(device *fz.Device) bool {
+			return device.PublicKey == publicKey
+		})
+
+	case !data.UserId.IsNull() && !data.Name.IsNull():
+		userId, name := data.UserId.ValueString(), data.Name.ValueString()
+
+		device = d.findDevice(resp, path.Root("name"), fmt.Sprintf("named %q for user %q", name, userId), func(device *fz.Device) bool {
+			return device.UserId == userId && device.Name == name
+		})
+
+	default:
+		resp.Diagnostics.AddError(
+			"Missing Device Lookup",
+			"Set one of id, public_key, or user_id and name to look up a device.",
+		)
+	}
+
+	if device == nil || resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(data.fromDevice(ctx, device)...)
+
+	// Write logs using the tflog package
+	// Documentation: https://terraform.io/plugin/log
+	tflog.Trace(ctx, "read a data source")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// findDevice returns the only device for which match is true. When there is
+// no such device or more than one, it adds an error for attribute, which
+// describes the devices looked for, and returns nil.
+func (d *DeviceDataSource) findDevice(resp *datasource.ReadResponse, attribute path.Path, description string, match func(*fz.Device) bool) *fz.Device {
+	devices, err := d.client.GetAllDevices()
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read devices, got error: %s", err))
+		return nil
+	}
+
+	var found []*fz.Device
+
+	for i := range *devices {
+		if match(&(*devices)[i]) {
+			found = append(found, &(*devices)[i])
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		resp.Diagnostics.AddAttributeError(
+			attribute,
+			"Device Not Found",
+			fmt.Sprintf("No device %s exists.", description),
+		)
+		return nil
+	case 1:
+		return found[0]
+	default:
+		ids := make([]string, 0, len(found))
+		for _, device := range found {
+			ids = append(ids, device.ID)
+		}
+		resp.Diagnostics.AddAttributeError(
+			attribute,
+			"Multiple Devices Found",
+			fmt.Sprintf("%d devices %s exist, with ids %v. Look the device up by id instead.", len(found), description, ids),
+		)
+		return nil
+	}
+}
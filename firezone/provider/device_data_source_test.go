@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDeviceDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: providerConfig + testAccDeviceDataSourceConfig + `
+data "firezone_device" "by_id" {
+  id = firezone_device.test.id
+}
+
+data "firezone_device" "by_public_key" {
+  public_key = firezone_device.test.public_key
+}
+
+data "firezone_device" "by_name" {
+  user_id = firezone_device.test.user_id
+  name    = firezone_device.test.name
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.firezone_device.by_id", "id", "firezone_device.test", "id"),
+					resource.TestCheckResourceAttrPair("data.firezone_device.by_id", "user_id", "firezone_user.test", "id"),
+					resource.TestCheckResourceAttr("data.firezone_device.by_id", "name", "lookup-laptop"),
+					resource.TestCheckResourceAttr("data.firezone_device.by_id", "description", "Looked up laptop"),
+					resource.TestCheckResourceAttr("data.firezone_device.by_id", "ipv4", "10.3.2.40"),
+					resource.TestCheckResourceAttrPair("data.firezone_device.by_id", "ipv6", "firezone_device.test", "ipv6"),
+					resource.TestCheckResourceAttrPair("data.firezone_device.by_id", "public_key", "firezone_device.test", "public_key"),
+					resource.TestCheckResourceAttrPair("data.firezone_device.by_id", "preshared_key", "firezone_device.test", "preshared_key"),
+					resource.TestCheckResourceAttr("data.firezone_device.by_id", "use_default_allowed_ips", "false"),
+					resource.TestCheckResourceAttr("data.firezone_device.by_id", "allowed_ips.#", "1"),
+					resource.TestCheckTypeSetElemAttr("data.firezone_device.by_id", "allowed_ips.*", "10.3.0.0/16"),
+
+					resource.TestCheckResourceAttrPair("data.firezone_device.by_public_key", "id", "firezone_device.test", "id"),
+					resource.TestCheckResourceAttr("data.firezone_device.by_public_key", "ipv4", "10.3.2.40"),
+
+					resource.TestCheckResourceAttrPair("data.firezone_device.by_name", "id", "firezone_device.test", "id"),
+					resource.TestCheckResourceAttr("data.firezone_device.by_name", "ipv4", "10.3.2.40"),
+				),
+			},
+			{
+				Config: providerConfig + testAccDeviceDataSourceConfig + `
+data "firezone_device" "missing" {
+  user_id = firezone_device.test.user_id
+  name    = "lookup-phone"
+}
+`,
+				ExpectError: regexp.MustCompile(`No\s+device\s+named\s+"lookup-phone"\s+for\s+user`),
+			},
+			{
+				Config: providerConfig + testAccDeviceDataSourceConfig + `
+data "firezone_device" "missing" {
+  id = "00000000-0000-0000-0000-000000000000"
+}
+`,
+				ExpectError: regexp.MustCompile(`No\s+device\s+with\s+id`),
+			},
+			{
+				PreConfig: func() { testAccFirezone.duplicateDevice("lookup-laptop") },
+				Config: providerConfig + testAccDeviceDataSourceConfig + `
+data "firezone_device" "ambiguous" {
+  user_id = firezone_device.test.user_id
+  name    = firezone_device.test.name
+}
+`,
+				ExpectError: regexp.MustCompile(`2\s+devices\s+named\s+"lookup-laptop"`),
+			},
+		},
+	})
+}
+
+func TestAccDeviceDataSource_invalidLookup(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+data "firezone_device" "test" {}
+`,
+				ExpectError: regexp.MustCompile(`No\s+attribute\s+specified`),
+			},
+			{
+				Config: providerConfig + `
+data "firezone_device" "test" {
+  id         = "00000000-0000-0000-0000-000000000000"
+  public_key = "Zm9vYmFyYmF6cXV4Zm9vYmFyYmF6cXV4Zm9vYmFyYmE="
+}
+`,
+				ExpectError: regexp.MustCompile(`2\s+attributes\s+specified`),
+			},
+			{
+				Config: providerConfig + `
+data "firezone_device" "test" {
+  name = "laptop"
+}
+`,
+				ExpectError: regexp.MustCompile(`Attribute\s+"user_id"\s+must\s+be\s+specified`),
+			},
+		},
+	})
+}
+
+const testAccDeviceDataSourceConfig = `
+resource "firezone_user" "test" {
+  email = "lookup@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_wireguard_keypair" "test" {}
+
+resource "firezone_device" "test" {
+  user_id                 = firezone_user.test.id
+  name                    = "lookup-laptop"
+  description             = "Looked up laptop"
+  public_key              = firezone_wireguard_keypair.test.public_key
+  ipv4                    = "10.3.2.40"
+  use_default_allowed_ips = false
+  allowed_ips             = ["10.3.0.0/16"]
+}
+`
@@ -3,13 +3,20 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sort"
+	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 
@@ -31,10 +38,10 @@ type DeviceResource struct {
 
 // DeviceResourceModel describes the resource data model.
 type DeviceResourceModel struct {
-	Id types.String `tfsdk:"id"`
-	// AllowedIPs                    types.List   `tfsdk:"allowed_ips"`
-	Description types.String `tfsdk:"description"`
-	// DNS                           types.List   `tfsdk:"dns"`
+	Id                            types.String `tfsdk:"id"`
+	AllowedIPs                    types.Set    `tfsdk:"allowed_ips"`
+	Description                   types.String `tfsdk:"description"`
+	DNS                           types.List   `tfsdk:"dns"`
 	Endpoint                      types.String `tfsdk:"endpoint"`
 	IPv4                          types.String `tfsdk:"ipv4"`
 	IPv6                          types.String `tfsdk:"ipv6"`
@@ -71,6 +78,9 @@ func (r *DeviceResource) Schema(ctx context.Context, req resource.SchemaRequest,
 				Optional:            true,
 				Computed:            true,
 				Sensitive:           true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"mtu": schema.Int64Attribute{
 				MarkdownDescription: "Device MTU",
@@ -116,21 +126,39 @@ func (r *DeviceResource) Schema(ctx context.Context, req resource.SchemaRequest,
 				MarkdownDescription: "Device IPv6",
 				Optional:            true,
 				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"ipv4": schema.StringAttribute{
 				MarkdownDescription: "Device IPv4",
 				Optional:            true,
 				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"description": schema.StringAttribute{
 				MarkdownDescription: "Device description",
 				Optional:            true,
 				Computed:            true,
 			},
-			// "allowed_ips": schema.ListAttribute{
-			// 	MarkdownDescription: "Device allowed ips",
-			// 	Optional:            true,
-			// },
+			"allowed_ips": schema.SetAttribute{
+				MarkdownDescription: "Device allowed ips in CIDR notation, used when `use_default_allowed_ips` is false",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.Set{
+					setvalidator.ValueStringsAre(isCIDR()),
+				},
+			},
+			"dns": schema.ListAttribute{
+				MarkdownDescription: "Device DNS servers in order of preference, used when `use_default_dns` is false",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(isIPAddress()),
+				},
+			},
 			"public_key": schema.StringAttribute{
 				MarkdownDescription: "Device public key",
 				Required:            true,
@@ -184,49 +212,21 @@ func (r *DeviceResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	device, err := r.client.CreateDevice(fz.Device{
-		UserId:      data.UserId.ValueString(),
-		Name:        data.Name.ValueString(),
-		PublicKey:   data.PublicKey.ValueString(),
-		Description: data.Description.ValueString(),
-		IPv4:        data.IPv4.ValueString(),
-		IPv6:        data.IPv6.ValueString(),
-		// AllowedIPs:  data.AllowedIPs.ValueList(),
-		Endpoint:     data.Endpoint.ValueString(),
-		PresharedKey: data.PresharedKey.ValueString(),
-		MTU:          int(data.MTU.ValueInt64()),
-		// DNS:                           data.DNS.ValueString(),
-		PersistentKeepalive:           int(data.PersistentKeepalive.ValueInt64()),
-		UseDefaultDNS:                 data.UseDefaultDNS.ValueBool(),
-		UseDefaultEndpoint:            data.UseDefaultEndpoint.ValueBool(),
-		UseDefaultMTU:                 data.UseDefaultMTU.ValueBool(),
-		UseDefaultAllowedIPs:          data.UseDefaultAllowedIPs.ValueBool(),
-		UseDefaultPersistentKeepalive: data.UseDefaultPersistentKeepalive.ValueBool(),
-	})
+	input, diags := data.toDevice(ctx)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	device, err := createDevice(r.client, input)
 
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create device, got error: %s", err))
 		return
 	}
 
-	data.Id = types.StringValue(device.ID)
-	data.UserId = types.StringValue(device.UserId)
-	data.Name = types.StringValue(device.Name)
-	data.PublicKey = types.StringValue(device.PublicKey)
-	data.Description = types.StringValue(device.Description)
-	data.IPv4 = types.StringValue(device.IPv4)
-	data.IPv6 = types.StringValue(device.IPv6)
-	// data.AllowedIPs, = types.ListValue(device.AllowedIPs)
-	data.Endpoint = types.StringValue(device.Endpoint)
-	data.PresharedKey = types.StringValue(device.PresharedKey)
-	data.MTU = types.Int64Value(int64(int64(device.MTU)))
-	// data.DNS = types.StringValue(device.DNS)
-	data.PersistentKeepalive = types.Int64Value(int64(device.PersistentKeepalive))
-	data.UseDefaultAllowedIPs = types.BoolValue(device.UseDefaultAllowedIPs)
-	data.UseDefaultDNS = types.BoolValue(device.UseDefaultDNS)
-	data.UseDefaultEndpoint = types.BoolValue(device.UseDefaultEndpoint)
-	data.UseDefaultMTU = types.BoolValue(device.UseDefaultMTU)
-	data.UseDefaultPersistentKeepalive = types.BoolValue(device.UseDefaultPersistentKeepalive)
+	resp.Diagnostics.Append(data.fromDevice(ctx, device)...)
 
 	// Write logs using the tflog package
 	// Documentation: https://terraform.io/plugin/log
@@ -248,29 +248,19 @@ func (r *DeviceResource) Read(ctx context.Context, req resource.ReadRequest, res
 
 	device, err := r.client.GetDevice(data.Id.ValueString())
 
+	if isNotFound(err) {
+		// The device was deleted outside of Terraform, e.g. in the admin UI.
+		tflog.Warn(ctx, "device not found, removing from state", map[string]interface{}{"id": data.Id.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read device, got error: %s", err))
 		return
 	}
 
-	data.Id = types.StringValue(device.ID)
-	data.UserId = types.StringValue(device.UserId)
-	data.Name = types.StringValue(device.Name)
-	data.PublicKey = types.StringValue(device.PublicKey)
-	data.Description = types.StringValue(device.Description)
-	data.IPv4 = types.StringValue(device.IPv4)
-	data.IPv6 = types.StringValue(device.IPv6)
-	// data.AllowedIPs, = types.ListValue(device.AllowedIPs)
-	data.Endpoint = types.StringValue(device.Endpoint)
-	data.PresharedKey = types.StringValue(device.PresharedKey)
-	data.MTU = types.Int64Value(int64(device.MTU))
-	// data.DNS = types.StringValue(device.DNS)
-	data.PersistentKeepalive = types.Int64Value(int64(device.PersistentKeepalive))
-	data.UseDefaultAllowedIPs = types.BoolValue(device.UseDefaultAllowedIPs)
-	data.UseDefaultDNS = types.BoolValue(device.UseDefaultDNS)
-	data.UseDefaultEndpoint = types.BoolValue(device.UseDefaultEndpoint)
-	data.UseDefaultMTU = types.BoolValue(device.UseDefaultMTU)
-	data.UseDefaultPersistentKeepalive = types.BoolValue(device.UseDefaultPersistentKeepalive)
+	resp.Diagnostics.Append(data.fromDevice(ctx, device)...)
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
@@ -286,49 +276,21 @@ func (r *DeviceResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	device, err := r.client.UpdateDevice(data.Id.ValueString(), fz.Device{
-		UserId:      data.UserId.ValueString(),
-		Name:        data.Name.ValueString(),
-		PublicKey:   data.PublicKey.ValueString(),
-		Description: data.Description.ValueString(),
-		IPv4:        data.IPv4.ValueString(),
-		IPv6:        data.IPv6.ValueString(),
-		// AllowedIPs:  data.AllowedIPs.ValueList(),
-		Endpoint:     data.Endpoint.ValueString(),
-		PresharedKey: data.PresharedKey.ValueString(),
-		MTU:          int(data.MTU.ValueInt64()),
-		// DNS:                           data.DNS.ValueString(),
-		PersistentKeepalive:           int(data.PersistentKeepalive.ValueInt64()),
-		UseDefaultDNS:                 data.UseDefaultDNS.ValueBool(),
-		UseDefaultEndpoint:            data.UseDefaultEndpoint.ValueBool(),
-		UseDefaultMTU:                 data.UseDefaultMTU.ValueBool(),
-		UseDefaultAllowedIPs:          data.UseDefaultAllowedIPs.ValueBool(),
-		UseDefaultPersistentKeepalive: data.UseDefaultPersistentKeepalive.ValueBool(),
-	})
+	input, diags := data.toDevice(ctx)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	device, err := updateDevice(r.client, data.Id.ValueString(), deviceParams(input))
 
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update device, got error: %s", err))
 		return
 	}
 
-	data.Id = types.StringValue(device.ID)
-	data.UserId = types.StringValue(device.UserId)
-	data.Name = types.StringValue(device.Name)
-	data.PublicKey = types.StringValue(device.PublicKey)
-	data.Description = types.StringValue(device.Description)
-	data.IPv4 = types.StringValue(device.IPv4)
-	data.IPv6 = types.StringValue(device.IPv6)
-	// data.AllowedIPs, = types.ListValue(device.AllowedIPs)
-	data.Endpoint = types.StringValue(device.Endpoint)
-	data.PresharedKey = types.StringValue(device.PresharedKey)
-	data.MTU = types.Int64Value(int64(device.MTU))
-	// data.DNS = types.StringValue(device.DNS)
-	data.PersistentKeepalive = types.Int64Value(int64(device.PersistentKeepalive))
-	data.UseDefaultAllowedIPs = types.BoolValue(device.UseDefaultAllowedIPs)
-	data.UseDefaultDNS = types.BoolValue(device.UseDefaultDNS)
-	data.UseDefaultEndpoint = types.BoolValue(device.UseDefaultEndpoint)
-	data.UseDefaultMTU = types.BoolValue(device.UseDefaultMTU)
-	data.UseDefaultPersistentKeepalive = types.BoolValue(device.UseDefaultPersistentKeepalive)
+	resp.Diagnostics.Append(data.fromDevice(ctx, device)...)
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
@@ -346,12 +308,225 @@ func (r *DeviceResource) Delete(ctx context.Context, req resource.DeleteRequest,
 
 	err := r.client.DeleteDevice(data.Id.ValueString())
 
+	// A device which is already gone is as good as deleted.
+	if isNotFound(err) {
+		return
+	}
+
 	if err != nil {
-		// resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete device, got error: %s", err))
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete device, got error: %s", err))
+		return
+	}
+
+	// Make sure the WireGuard peer is really gone before Terraform forgets
+	// about it.
+	_, err = r.client.GetDevice(data.Id.ValueString())
+
+	if err == nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Device %s still exists after it was deleted", data.Id.ValueString()))
 		return
 	}
+
+	if !isNotFound(err) {
+		tflog.Warn(ctx, "unable to verify device deletion", map[string]interface{}{"id": data.Id.ValueString(), "error": err.Error()})
+	}
 }
 
 func (r *DeviceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	// Devices are imported by id, by public key, or by user email or id and
+	// device name separated by a slash.
+	if isUUID(req.ID) {
+		resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+		return
+	}
+
+	var match func(device *fz.Device) bool
+
+	if isWireGuardKey(req.ID) {
+		match = func(device *fz.Device) bool {
+			return device.PublicKey == req.ID
+		}
+	} else {
+		user, name, found := strings.Cut(req.ID, "/")
+
+		if !found || user == "" || name == "" {
+			resp.Diagnostics.AddError(
+				"Unexpected Import Identifier",
+				fmt.Sprintf("Expected a device id, a public key or <user email or id>/<device name>. Got: %q", req.ID),
+			)
+			return
+		}
+
+		userId, ok := importUserID(r.client, &resp.Diagnostics, user)
+
+		if !ok {
+			return
+		}
+
+		match = func(device *fz.Device) bool {
+			return device.UserId == userId && device.Name == name
+		}
+	}
+
+	devices, err := r.client.GetAllDevices()
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read devices, got error: %s", err))
+		return
+	}
+
+	var ids []string
+
+	for i := range *devices {
+		if match(&(*devices)[i]) {
+			ids = append(ids, (*devices)[i].ID)
+		}
+	}
+
+	id, ok := importMatch(&resp.Diagnostics, "device", req.ID, ids)
+
+	if !ok {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+}
+
+// toDevice converts the model into the Firezone device sent on create and update.
+func (m *DeviceResourceModel) toDevice(ctx context.Context) (fz.Device, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var allowedIPs, dns []string
+
+	diags.Append(m.AllowedIPs.ElementsAs(ctx, &allowedIPs, true)...)
+	diags.Append(m.DNS.ElementsAs(ctx, &dns, true)...)
+
+	// Keep allowed ips in a stable order; the set itself is unordered.
+	sort.Strings(allowedIPs)
+
+	// Unset lists clear the device's routes and resolvers.
+	allowedIPs, dns = nonNilStrings(allowedIPs), nonNilStrings(dns)
+
+	return fz.Device{
+		UserId:                        m.UserId.ValueString(),
+		Name:                          m.Name.ValueString(),
+		PublicKey:                     m.PublicKey.ValueString(),
+		Description:                   m.Description.ValueString(),
+		IPv4:                          m.IPv4.ValueString(),
+		IPv6:                          m.IPv6.ValueString(),
+		AllowedIPs:                    allowedIPs,
+		Endpoint:                      m.Endpoint.ValueString(),
+		PresharedKey:                  m.PresharedKey.ValueString(),
+		MTU:                           int(m.MTU.ValueInt64()),
+		DNS:                           dns,
+		PersistentKeepalive:           int(m.PersistentKeepalive.ValueInt64()),
+		UseDefaultDNS:                 m.UseDefaultDNS.ValueBool(),
+		UseDefaultEndpoint:            m.UseDefaultEndpoint.ValueBool(),
+		UseDefaultMTU:                 m.UseDefaultMTU.ValueBool(),
+		UseDefaultAllowedIPs:          m.UseDefaultAllowedIPs.ValueBool(),
+		UseDefaultPersistentKeepalive: m.UseDefaultPersistentKeepalive.ValueBool(),
+	}, diags
+}
+
+// fromDevice copies a Firezone device into the model.
+func (m *DeviceResourceModel) fromDevice(ctx context.Context, device *fz.Device) diag.Diagnostics {
+	var diags, d diag.Diagnostics
+
+	m.Id = types.StringValue(device.ID)
+	m.UserId = types.StringValue(device.UserId)
+	m.Name = types.StringValue(device.Name)
+	m.PublicKey = types.StringValue(device.PublicKey)
+	m.Description = types.StringValue(device.Description)
+	m.IPv4 = types.StringValue(device.IPv4)
+	m.IPv6 = types.StringValue(device.IPv6)
+	m.Endpoint = types.StringValue(device.Endpoint)
+	m.PresharedKey = types.StringValue(device.PresharedKey)
+	m.MTU = types.Int64Value(int64(device.MTU))
+	m.PersistentKeepalive = types.Int64Value(int64(device.PersistentKeepalive))
+	m.UseDefaultAllowedIPs = types.BoolValue(device.UseDefaultAllowedIPs)
+	m.UseDefaultDNS = types.BoolValue(device.UseDefaultDNS)
+	m.UseDefaultEndpoint = types.BoolValue(device.UseDefaultEndpoint)
+	m.UseDefaultMTU = types.BoolValue(device.UseDefaultMTU)
+	m.UseDefaultPersistentKeepalive = types.BoolValue(device.UseDefaultPersistentKeepalive)
+
+	// allowed_ips and dns are not computed, so they stay null while they are
+	// unset and the device has none.
+	if len(device.AllowedIPs) > 0 || !m.AllowedIPs.IsNull() {
+		m.AllowedIPs, d = types.SetValueFrom(ctx, types.StringType, nonNilStrings(device.AllowedIPs))
+		diags.Append(d...)
+	}
+	if len(device.DNS) > 0 || !m.DNS.IsNull() {
+		m.DNS, d = types.ListValueFrom(ctx, types.StringType, nonNilStrings(device.DNS))
+		diags.Append(d...)
+	}
+
+	return diags
+}
+
+// createDevice creates a device with all of its fields in one request. The
+// Firezone client leaves the use_default_* flags and the preshared key out
+// of creates.
+func createDevice(client *fz.Client, input fz.Device) (*fz.Device, error) {
+	var device fz.Device
+
+	params := deviceParams(input)
+
+	// Let Firezone generate whatever is left unset.
+	params["preshared_key"] = nullIfEmpty(input.PresharedKey)
+	params["ipv4"] = nullIfEmpty(input.IPv4)
+	params["ipv6"] = nullIfEmpty(input.IPv6)
+
+	body := map[string]interface{}{"device": params}
+
+	if err := apiRequest(client, http.MethodPost, "/v0/devices", body, &device); err != nil {
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+// updateDevice changes the given device fields, leaving all others as they
+// are. The Firezone client sends updates without the "device" wrapper and
+// with the read-only fields of the device.
+func updateDevice(client *fz.Client, id string, params map[string]interface{}) (*fz.Device, error) {
+	var device fz.Device
+
+	body := map[string]interface{}{"device": params}
+
+	if err := apiRequest(client, http.MethodPatch, "/v0/devices/"+id, body, &device); err != nil {
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+// deviceParams returns the writable fields of a device.
+func deviceParams(input fz.Device) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":                          input.UserId,
+		"name":                             input.Name,
+		"description":                      input.Description,
+		"public_key":                       input.PublicKey,
+		"preshared_key":                    input.PresharedKey,
+		"ipv4":                             input.IPv4,
+		"ipv6":                             input.IPv6,
+		"allowed_ips":                      input.AllowedIPs,
+		"dns":                              input.DNS,
+		"endpoint":                         input.Endpoint,
+		"mtu":                              input.MTU,
+		"persistent_keepalive":             input.PersistentKeepalive,
+		"use_default_allowed_ips":          input.UseDefaultAllowedIPs,
+		"use_default_dns":                  input.UseDefaultDNS,
+		"use_default_endpoint":             input.UseDefaultEndpoint,
+		"use_default_mtu":                  input.UseDefaultMTU,
+		"use_default_persistent_keepalive": input.UseDefaultPersistentKeepalive,
+	}
+}
+
+// nonNilStrings returns an empty slice for nil so it maps to an empty
+// collection rather than null.
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
 }
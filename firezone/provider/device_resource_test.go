@@ -2,23 +2,35 @@ package provider
 
 import (
 	"fmt"
+	"net/http"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 )
 
+const testAccDevicePublicKey = "+5l6DkeC7EjO/k+KxmKR3altmfCvjHrSOB240ccmBCg="
+
 func TestAccDeviceResource(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		PreCheck:                 func() { testAccPreCheck(t) },
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckDeviceResourceDestroy,
 		Steps: []resource.TestStep{
 			// Create and Read testing
 			{
-				Config: providerConfig + testAccDeviceResourceConfig(),
+				Config: providerConfig + testAccDeviceResourceConfig("laptop", "first laptop"),
 				Check: resource.ComposeAggregateTestCheckFunc(
-					resource.TestCheckResourceAttr("firezone_device.test", "email", "one@example.com"),
-					resource.TestCheckResourceAttr("firezone_device.test", "role", "unprivileged"),
-					resource.TestCheckResourceAttr("firezone_device.test", "id", "example-id"),
+					resource.TestCheckResourceAttrPair("firezone_device.test", "user_id", "firezone_user.test", "id"),
+					resource.TestCheckResourceAttr("firezone_device.test", "name", "laptop"),
+					resource.TestCheckResourceAttr("firezone_device.test", "description", "first laptop"),
+					resource.TestCheckResourceAttr("firezone_device.test", "public_key", testAccDevicePublicKey),
+					resource.TestMatchResourceAttr("firezone_device.test", "ipv4", regexp.MustCompile(`^10\.3\.2\.\d+$`)),
+					resource.TestMatchResourceAttr("firezone_device.test", "ipv6", regexp.MustCompile(`^fd00::3:2:[0-9a-f]+$`)),
+					resource.TestCheckResourceAttrSet("firezone_device.test", "preshared_key"),
+					resource.TestCheckResourceAttr("firezone_device.test", "use_default_dns", "true"),
+					resource.TestCheckResourceAttrSet("firezone_device.test", "id"),
 				),
 			},
 			// ImportState testing
@@ -26,18 +38,71 @@ func TestAccDeviceResource(t *testing.T) {
 				ResourceName:      "firezone_device.test",
 				ImportState:       true,
 				ImportStateVerify: true,
-				// This is not normally necessary, but is here because this
-				// example code does not have an actual upstream service.
-				// Once the Read method is able to refresh information from
-				// the upstream service, this can be removed.
-				ImportStateVerifyIgnore: []string{"email", "defaulted"},
+			},
+			{
+				ResourceName:      "firezone_device.test",
+				ImportState:       true,
+				ImportStateId:     "device@example.com/laptop",
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "firezone_device.test",
+				ImportState:       true,
+				ImportStateIdFunc: testAccImportStateIdFunc("firezone_device.test", "user_id", "name"),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "firezone_device.test",
+				ImportState:       true,
+				ImportStateId:     testAccDevicePublicKey,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:  "firezone_device.test",
+				ImportState:   true,
+				ImportStateId: "device@example.com/phone",
+				ExpectError:   regexp.MustCompile(`No\s+device\s+matches\s+the\s+import\s+identifier`),
+			},
+			{
+				ResourceName:  "firezone_device.test",
+				ImportState:   true,
+				ImportStateId: "laptop",
+				ExpectError:   regexp.MustCompile(`Expected\s+a\s+device\s+id,\s+a\s+public\s+key`),
 			},
 			// Update and Read testing
 			{
-				Config: testAccDeviceResourceConfig(),
+				Config: providerConfig + testAccDeviceResourceConfig("workstation", "moved to desk"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_device.test", "name", "workstation"),
+					resource.TestCheckResourceAttr("firezone_device.test", "description", "moved to desk"),
+				),
+			},
+			// Custom routes and resolvers
+			{
+				Config: providerConfig + testAccDeviceResourceConfigWithRoutes(`["10.0.0.0/8", "192.168.1.0/24"]`, `["9.9.9.9", "1.1.1.1"]`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_device.test", "use_default_allowed_ips", "false"),
+					resource.TestCheckResourceAttr("firezone_device.test", "use_default_dns", "false"),
+					resource.TestCheckResourceAttr("firezone_device.test", "allowed_ips.#", "2"),
+					resource.TestCheckTypeSetElemAttr("firezone_device.test", "allowed_ips.*", "10.0.0.0/8"),
+					resource.TestCheckTypeSetElemAttr("firezone_device.test", "allowed_ips.*", "192.168.1.0/24"),
+					resource.TestCheckResourceAttr("firezone_device.test", "dns.#", "2"),
+					resource.TestCheckResourceAttr("firezone_device.test", "dns.0", "9.9.9.9"),
+					resource.TestCheckResourceAttr("firezone_device.test", "dns.1", "1.1.1.1"),
+				),
+			},
+			// Reordering the set does not produce a diff
+			{
+				Config:   providerConfig + testAccDeviceResourceConfigWithRoutes(`["192.168.1.0/24", "10.0.0.0/8"]`, `["9.9.9.9", "1.1.1.1"]`),
+				PlanOnly: true,
+			},
+			// Removing the lists clears them
+			{
+				Config: providerConfig + testAccDeviceResourceConfigWithoutRoutes(),
 				Check: resource.ComposeAggregateTestCheckFunc(
-					resource.TestCheckResourceAttr("firezone_device.test", "email", "two@example.com"),
-					resource.TestCheckResourceAttr("firezone_device.test", "role", "unprivileged"),
+					resource.TestCheckNoResourceAttr("firezone_device.test", "allowed_ips.#"),
+					resource.TestCheckNoResourceAttr("firezone_device.test", "dns.#"),
+					testAccCheckDeviceRoutesCleared("firezone_device.test"),
 				),
 			},
 			// Delete testing automatically occurs in TestCase
@@ -45,14 +110,233 @@ func TestAccDeviceResource(t *testing.T) {
 	})
 }
 
-func testAccDeviceResourceConfig() string {
+func TestAccDeviceResource_importAmbiguous(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckDeviceResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccDeviceResourceConfig("laptop", "first laptop"),
+			},
+			{
+				PreConfig:     func() { testAccFirezone.duplicateDevice("laptop") },
+				ResourceName:  "firezone_device.test",
+				ImportState:   true,
+				ImportStateId: "device@example.com/laptop",
+				ExpectError:   regexp.MustCompile(`2\s+devices\s+match\s+the\s+import\s+identifier`),
+			},
+		},
+	})
+}
+
+func TestAccDeviceResource_routesOnCreate(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckDeviceResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccDeviceResourceConfigWithRoutes(`["fd00::/8"]`, `["2606:4700:4700::1111"]`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_device.test", "use_default_allowed_ips", "false"),
+					resource.TestCheckResourceAttr("firezone_device.test", "allowed_ips.#", "1"),
+					resource.TestCheckResourceAttr("firezone_device.test", "dns.0", "2606:4700:4700::1111"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDeviceResource_invalidRoutes(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      providerConfig + testAccDeviceResourceConfigWithRoutes(`["10.0.0.0/33"]`, `["1.1.1.1"]`),
+				ExpectError: regexp.MustCompile(`network\s+in\s+CIDR\s+notation`),
+			},
+			{
+				Config:      providerConfig + testAccDeviceResourceConfigWithRoutes(`["10.0.0.0/8"]`, `["dns.example.com"]`),
+				ExpectError: regexp.MustCompile(`must\s+be\s+an\s+IPv4\s+or\s+IPv6\s+address`),
+			},
+		},
+	})
+}
+
+func TestAccDeviceResource_invalidPublicKey(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "firezone_user" "test" {
+  email = "invalidkey@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_device" "test" {
+  user_id    = firezone_user.test.id
+  name       = "broken"
+  public_key = "not-a-key"
+}
+`,
+				ExpectError: regexp.MustCompile(`public_key`),
+			},
+		},
+	})
+}
+
+func TestAccDeviceResource_disappears(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckDeviceResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:             providerConfig + testAccDeviceResourceConfig("laptop", "removed in the UI"),
+				Check:              testAccCheckDisappears("firezone_device.test", testAccFirezone.deleteDevice),
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccDeviceResource_deleteError(t *testing.T) {
+	var id string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckDeviceResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccDeviceResourceConfig("laptop", "hard to delete"),
+				Check: func(s *terraform.State) error {
+					id = s.RootModule().Resources["firezone_device.test"].Primary.ID
+					return nil
+				},
+			},
+			// The API refuses the delete
+			{
+				PreConfig: func() {
+					testAccFirezone.respondOnce(http.MethodDelete, "/v0/devices/"+id, http.StatusForbidden)
+				},
+				Config:      providerConfig + testAccDeviceResourceConfig("laptop", "hard to delete"),
+				Destroy:     true,
+				ExpectError: regexp.MustCompile(`Unable to delete device`),
+			},
+			// A server error is reported once the retries run out
+			{
+				PreConfig: func() {
+					testAccFirezone.respondOnce(http.MethodDelete, "/v0/devices/"+id, http.StatusInternalServerError)
+				},
+				Config:      testAccProviderNoRetriesConfig() + testAccDeviceResourceConfig("laptop", "hard to delete"),
+				Destroy:     true,
+				ExpectError: regexp.MustCompile(`Unable to delete device`),
+			},
+			// The API claims success but the device is still there
+			{
+				PreConfig: func() {
+					testAccFirezone.respondOnce(http.MethodDelete, "/v0/devices/"+id, http.StatusNoContent)
+				},
+				Config:      providerConfig + testAccDeviceResourceConfig("laptop", "hard to delete"),
+				Destroy:     true,
+				ExpectError: regexp.MustCompile(`still exists after it was deleted`),
+			},
+			// The device is still managed and gets deleted at the end
+			{
+				Config: providerConfig + testAccDeviceResourceConfig("laptop", "hard to delete"),
+				Check: func(s *terraform.State) error {
+					if testAccFirezone.device(id) == nil {
+						return fmt.Errorf("device %s was deleted", id)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func testAccCheckDeviceResourceDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "firezone_device" {
+			continue
+		}
+		if testAccFirezone.device(rs.Primary.ID) != nil {
+			return fmt.Errorf("device %s still exists", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccDeviceResourceConfig(name string, description string) string {
+	return fmt.Sprintf(`
+resource "firezone_user" "test" {
+  email = "device@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_device" "test" {
+  user_id     = firezone_user.test.id
+  name        = %[1]q
+  description = %[2]q
+  public_key  = %[3]q
+}
+`, name, description, testAccDevicePublicKey)
+}
+
+func testAccCheckDeviceRoutesCleared(name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+		device := testAccFirezone.device(rs.Primary.ID)
+		if device == nil {
+			return fmt.Errorf("device %s does not exist", rs.Primary.ID)
+		}
+		if len(device.AllowedIPs) > 0 || len(device.DNS) > 0 {
+			return fmt.Errorf("device %s still has allowed ips %v and dns %v", rs.Primary.ID, device.AllowedIPs, device.DNS)
+		}
+		return nil
+	}
+}
+
+func testAccDeviceResourceConfigWithRoutes(allowedIPs string, dns string) string {
+	return fmt.Sprintf(`
+resource "firezone_user" "test" {
+  email = "routes@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_device" "test" {
+  user_id                 = firezone_user.test.id
+  name                    = "router"
+  public_key              = %[1]q
+  use_default_allowed_ips = false
+  allowed_ips             = %[2]s
+  use_default_dns         = false
+  dns                     = %[3]s
+}
+`, testAccDevicePublicKey, allowedIPs, dns)
+}
+
+func testAccDeviceResourceConfigWithoutRoutes() string {
 	return fmt.Sprintf(`
+resource "firezone_user" "test" {
+  email = "routes@example.com"
+  role  = "unprivileged"
+}
+
 resource "firezone_device" "test" {
-	user_id = "example-id"
-	action = "allow"
-	destination = "0.0.0.0/0"
-	port_range = "0-65535"
-	port_type = "tcp"
+  user_id                 = firezone_user.test.id
+  name                    = "router"
+  public_key              = %[1]q
+  use_default_allowed_ips = false
+  use_default_dns         = false
 }
-`)
+`, testAccDevicePublicKey)
 }
@@ -0,0 +1,414 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &DevicesDataSource{}
+
+func NewDevicesDataSource() datasource.DataSource {
+	return &DevicesDataSource{}
+}
+
+// DevicesDataSource defines the data source implementation.
+type DevicesDataSource struct {
+	client *fz.Client
+}
+
+// DevicesDataSourceModel describes the data source data model.
+type DevicesDataSourceModel struct {
+	Id                   types.String             `tfsdk:"id"`
+	UserId               types.String             `tfsdk:"user_id"`
+	NameRegex            types.String             `tfsdk:"name_regex"`
+	CIDR                 types.String             `tfsdk:"cidr"`
+	IncludePresharedKeys types.Bool               `tfsdk:"include_preshared_keys"`
+	Devices              []DeviceDataSourceDevice `tfsdk:"devices"`
+}
+
+// DeviceDataSourceDevice describes a device returned by a data source.
+type DeviceDataSourceDevice struct {
+	Id                            types.String `tfsdk:"id"`
+	UserId                        types.String `tfsdk:"user_id"`
+	Name                          types.String `tfsdk:"name"`
+	Description                   types.String `tfsdk:"description"`
+	PublicKey                     types.String `tfsdk:"public_key"`
+	PresharedKey                  types.String `tfsdk:"preshared_key"`
+	ServerPublicKey               types.String `tfsdk:"server_public_key"`
+	IPv4                          types.String `tfsdk:"ipv4"`
+	IPv6                          types.String `tfsdk:"ipv6"`
+	AllowedIPs                    types.List   `tfsdk:"allowed_ips"`
+	DNS                           types.List   `tfsdk:"dns"`
+	Endpoint                      types.String `tfsdk:"endpoint"`
+	MTU                           types.Int64  `tfsdk:"mtu"`
+	PersistentKeepalive           types.Int64  `tfsdk:"persistent_keepalive"`
+	UseDefaultAllowedIPs          types.Bool   `tfsdk:"use_default_allowed_ips"`
+	UseDefaultDNS                 types.Bool   `tfsdk:"use_default_dns"`
+	UseDefaultEndpoint            types.Bool   `tfsdk:"use_default_endpoint"`
+	UseDefaultMTU                 types.Bool   `tfsdk:"use_default_mtu"`
+	UseDefaultPersistentKeepalive types.Bool   `tfsdk:"use_default_persistent_keepalive"`
+	LatestHandshake               types.String `tfsdk:"latest_handshake"`
+	RemoteIP                      types.String `tfsdk:"remote_ip"`
+	RXBytes                       types.Int64  `tfsdk:"rx_bytes"`
+	TXBytes                       types.Int64  `tfsdk:"tx_bytes"`
+	InsertedAt                    types.String `tfsdk:"inserted_at"`
+	UpdatedAt                     types.String `tfsdk:"updated_at"`
+}
+
+func (d *DevicesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_devices"
+}
+
+func (d *DevicesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Devices data source. Lists all devices matching every given filter.",
+
+		Attributes: map[string]schema.Attribute{
+			"user_id": schema.StringAttribute{
+				MarkdownDescription: "Only list devices of this user",
+				Optional:            true,
+			},
+			"name_regex": schema.StringAttribute{
+				MarkdownDescription: "Only list devices with a name matching this regular expression",
+				Optional:            true,
+				Validators: []validator.String{
+					isRegex(),
+				},
+			},
+			"cidr": schema.StringAttribute{
+				MarkdownDescription: "Only list devices with an IPv4 or IPv6 address within this network",
+				Optional:            true,
+				Validators: []validator.String{
+					isCIDR(),
+				},
+			},
+			"include_preshared_keys": schema.BoolAttribute{
+				MarkdownDescription: "Return the preshared keys of the devices, which are null otherwise",
+				Optional:            true,
+			},
+			"devices": schema.ListNestedAttribute{
+				MarkdownDescription: "Matching devices, sorted by name",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: deviceDataSourceAttributes(),
+				},
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Data source identifier",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *DevicesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fz.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *DevicesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data DevicesDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var nameRegex *regexp.Regexp
+	var cidr *netip.Prefix
+
+	// The filters are validated at plan time unless they were unknown then.
+	if !data.NameRegex.IsNull() {
+		var err error
+		nameRegex, err = regexp.Compile(data.NameRegex.ValueString())
+
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("name_regex"), "Invalid Attribute Value", err.Error())
+			return
+		}
+	}
+
+	if !data.CIDR.IsNull() {
+		prefix, err := netip.ParsePrefix(data.CIDR.ValueString())
+
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("cidr"), "Invalid Attribute Value", err.Error())
+			return
+		}
+
+		prefix = prefix.Masked()
+		cidr = &prefix
+	}
+
+	devices, err := d.client.GetAllDevices()
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read devices, got error: %s", err))
+		return
+	}
+
+	data.Devices = []DeviceDataSourceDevice{}
+
+	for i := range *devices {
+		device := &(*devices)[i]
+
+		if !data.UserId.IsNull() && device.UserId != data.UserId.ValueString() {
+			continue
+		}
+
+		if nameRegex != nil && !nameRegex.MatchString(device.Name) {
+			continue
+		}
+
+		if cidr != nil && !deviceInNetwork(device, *cidr) {
+			continue
+		}
+
+		var element DeviceDataSourceDevice
+		resp.Diagnostics.Append(element.fromDevice(ctx, device, data.IncludePresharedKeys.ValueBool())...)
+		data.Devices = append(data.Devices, element)
+	}
+
+	// The API returns devices in creation order, which is not stable when
+	// Terraform creates them in parallel.
+	sort.SliceStable(data.Devices, func(i, j int) bool {
+		return data.Devices[i].Name.ValueString() < data.Devices[j].Name.ValueString()
+	})
+
+	data.Id = types.StringValue("devices")
+
+	// Write logs using the tflog package
+	// Documentation: https://terraform.io/plugin/log
+	tflog.Trace(ctx, "read a data source")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// deviceDataSourceAttributes returns the schema of the devices returned by
+// data sources.
+func deviceDataSourceAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			MarkdownDescription: "Device identifier",
+			Computed:            true,
+		},
+		"user_id": schema.StringAttribute{
+			MarkdownDescription: "Device user id",
+			Computed:            true,
+		},
+		"name": schema.StringAttribute{
+			MarkdownDescription: "Device name",
+			Computed:            true,
+		},
+		"description": schema.StringAttribute{
+			MarkdownDescription: "Device description",
+			Computed:            true,
+		},
+		"public_key": schema.StringAttribute{
+			MarkdownDescription: "Device public key",
+			Computed:            true,
+		},
+		"preshared_key": schema.StringAttribute{
+			MarkdownDescription: "Device preshared key, only set when requested",
+			Computed:            true,
+			Sensitive:           true,
+		},
+		"server_public_key": schema.StringAttribute{
+			MarkdownDescription: "Public key of the Firezone WireGuard server",
+			Computed:            true,
+		},
+		"ipv4": schema.StringAttribute{
+			MarkdownDescription: "Device IPv4",
+			Computed:            true,
+		},
+		"ipv6": schema.StringAttribute{
+			MarkdownDescription: "Device IPv6",
+			Computed:            true,
+		},
+		"allowed_ips": schema.ListAttribute{
+			MarkdownDescription: "Device allowed ips, used when `use_default_allowed_ips` is false",
+			ElementType:         types.StringType,
+			Computed:            true,
+		},
+		"dns": schema.ListAttribute{
+			MarkdownDescription: "Device DNS servers, used when `use_default_dns` is false",
+			ElementType:         types.StringType,
+			Computed:            true,
+		},
+		"endpoint": schema.StringAttribute{
+			MarkdownDescription: "Device endpoint",
+			Computed:            true,
+		},
+		"mtu": schema.Int64Attribute{
+			MarkdownDescription: "Device MTU",
+			Computed:            true,
+		},
+		"persistent_keepalive": schema.Int64Attribute{
+			MarkdownDescription: "Device persistent keepalive",
+			Computed:            true,
+		},
+		"use_default_allowed_ips": schema.BoolAttribute{
+			MarkdownDescription: "Device use default allowed ips",
+			Computed:            true,
+		},
+		"use_default_dns": schema.BoolAttribute{
+			MarkdownDescription: "Device use default DNS",
+			Computed:            true,
+		},
+		"use_default_endpoint": schema.BoolAttribute{
+			MarkdownDescription: "Device use default endpoint",
+			Computed:            true,
+		},
+		"use_default_mtu": schema.BoolAttribute{
+			MarkdownDescription: "Device use default MTU",
+			Computed:            true,
+		},
+		"use_default_persistent_keepalive": schema.BoolAttribute{
+			MarkdownDescription: "Device use default persistent keepalive",
+			Computed:            true,
+		},
+		"latest_handshake": schema.StringAttribute{
+			MarkdownDescription: "Time of the latest WireGuard handshake",
+			Computed:            true,
+		},
+		"remote_ip": schema.StringAttribute{
+			MarkdownDescription: "IP address the device last connected from",
+			Computed:            true,
+		},
+		"rx_bytes": schema.Int64Attribute{
+			MarkdownDescription: "Bytes received from the device",
+			Computed:            true,
+		},
+		"tx_bytes": schema.Int64Attribute{
+			MarkdownDescription: "Bytes sent to the device",
+			Computed:            true,
+		},
+		"inserted_at": schema.StringAttribute{
+			MarkdownDescription: "Device creation time",
+			Computed:            true,
+		},
+		"updated_at": schema.StringAttribute{
+			MarkdownDescription: "Device last update time",
+			Computed:            true,
+		},
+	}
+}
+
+// fromDevice copies a Firezone device into the model. The preshared key is
+// left null unless includePresharedKey is set.
+func (m *DeviceDataSourceDevice) fromDevice(ctx context.Context, device *fz.Device, includePresharedKey bool) diag.Diagnostics {
+	var diags, d diag.Diagnostics
+
+	m.Id = types.StringValue(device.ID)
+	m.UserId = types.StringValue(device.UserId)
+	m.Name = types.StringValue(device.Name)
+	m.Description = types.StringValue(device.Description)
+	m.PublicKey = types.StringValue(device.PublicKey)
+	m.PresharedKey = types.StringNull()
+	m.ServerPublicKey = types.StringValue(device.ServerPublicKey)
+	m.IPv4 = types.StringValue(device.IPv4)
+	m.IPv6 = types.StringValue(device.IPv6)
+	m.Endpoint = types.StringValue(device.Endpoint)
+	m.MTU = types.Int64Value(int64(device.MTU))
+	m.PersistentKeepalive = types.Int64Value(int64(device.PersistentKeepalive))
+	m.UseDefaultAllowedIPs = types.BoolValue(device.UseDefaultAllowedIPs)
+	m.UseDefaultDNS = types.BoolValue(device.UseDefaultDNS)
+	m.UseDefaultEndpoint = types.BoolValue(device.UseDefaultEndpoint)
+	m.UseDefaultMTU = types.BoolValue(device.UseDefaultMTU)
+	m.UseDefaultPersistentKeepalive = types.BoolValue(device.UseDefaultPersistentKeepalive)
+	m.LatestHandshake = jsonString(device.LatestHandshake)
+	m.RemoteIP = jsonString(device.RemoteIP)
+	m.RXBytes = jsonInt64(device.RXBytes)
+	m.TXBytes = jsonInt64(device.TXBytes)
+	m.InsertedAt = types.StringValue(device.InsertedAt)
+	m.UpdatedAt = types.StringValue(device.UpdatedAt)
+
+	if includePresharedKey {
+		m.PresharedKey = types.StringValue(device.PresharedKey)
+	}
+
+	m.AllowedIPs, d = types.ListValueFrom(ctx, types.StringType, nonNilStrings(device.AllowedIPs))
+	diags.Append(d...)
+	m.DNS, d = types.ListValueFrom(ctx, types.StringType, nonNilStrings(device.DNS))
+	diags.Append(d...)
+
+	return diags
+}
+
+// deviceInNetwork reports whether the IPv4 or IPv6 address of device is
+// within network.
+func deviceInNetwork(device *fz.Device, network netip.Prefix) bool {
+	for _, address := range []string{device.IPv4, device.IPv6} {
+		if addr, ok := parseDeviceAddress(address); ok && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDeviceAddress parses a device address, which Firezone returns
+// without a prefix length but might return as a host route.
+func parseDeviceAddress(address string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(address); err == nil {
+		return addr, true
+	}
+	if prefix, err := netip.ParsePrefix(address); err == nil {
+		return prefix.Addr(), true
+	}
+	return netip.Addr{}, false
+}
+
+// jsonString converts a decoded JSON value of unknown type into a string,
+// mapping JSON null to a null value.
+func jsonString(value interface{}) types.String {
+	switch v := value.(type) {
+	case nil:
+		return types.StringNull()
+	case string:
+		return types.StringValue(v)
+	default:
+		return types.StringValue(fmt.Sprint(v))
+	}
+}
+
+// jsonInt64 converts a decoded JSON number into an integer, mapping JSON
+// null and other types to a null value.
+func jsonInt64(value interface{}) types.Int64 {
+	if v, ok := value.(float64); ok {
+		return types.Int64Value(int64(v))
+	}
+	return types.Int64Null()
+}
@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDevicesDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: providerConfig + testAccDevicesDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.firezone_devices.by_user", "devices.#", "2"),
+					resource.TestCheckResourceAttrPair("data.firezone_devices.by_user", "devices.0.id", "firezone_device.laptop", "id"),
+					resource.TestCheckResourceAttr("data.firezone_devices.by_user", "devices.0.name", "laptop"),
+					resource.TestCheckResourceAttr("data.firezone_devices.by_user", "devices.0.ipv4", "10.3.2.10"),
+					resource.TestCheckResourceAttrPair("data.firezone_devices.by_user", "devices.0.public_key", "firezone_device.laptop", "public_key"),
+					resource.TestCheckResourceAttr("data.firezone_devices.by_user", "devices.0.server_public_key", fakeServerPublicKey),
+					resource.TestCheckResourceAttr("data.firezone_devices.by_user", "devices.0.use_default_dns", "true"),
+					resource.TestCheckNoResourceAttr("data.firezone_devices.by_user", "devices.0.preshared_key"),
+					resource.TestCheckNoResourceAttr("data.firezone_devices.by_user", "devices.0.latest_handshake"),
+					resource.TestCheckResourceAttrPair("data.firezone_devices.by_user", "devices.1.id", "firezone_device.phone", "id"),
+
+					resource.TestCheckResourceAttr("data.firezone_devices.by_name", "devices.#", "1"),
+					resource.TestCheckResourceAttrPair("data.firezone_devices.by_name", "devices.0.id", "firezone_device.phone", "id"),
+
+					resource.TestCheckResourceAttr("data.firezone_devices.by_ipv4", "devices.#", "1"),
+					resource.TestCheckResourceAttrPair("data.firezone_devices.by_ipv4", "devices.0.id", "firezone_device.server", "id"),
+
+					resource.TestCheckResourceAttr("data.firezone_devices.by_ipv6", "devices.#", "1"),
+					resource.TestCheckResourceAttrPair("data.firezone_devices.by_ipv6", "devices.0.id", "firezone_device.laptop", "id"),
+
+					resource.TestCheckResourceAttr("data.firezone_devices.with_keys", "devices.#", "2"),
+					resource.TestCheckResourceAttrPair("data.firezone_devices.with_keys", "devices.0.preshared_key", "firezone_device.laptop", "preshared_key"),
+
+					resource.TestCheckResourceAttr("data.firezone_devices.none", "devices.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDevicesDataSource_invalidFilters(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+data "firezone_devices" "test" {
+  name_regex = "laptop("
+}
+`,
+				ExpectError: regexp.MustCompile(`must\s+be\s+a\s+valid\s+regular\s+expression`),
+			},
+			{
+				Config: providerConfig + `
+data "firezone_devices" "test" {
+  cidr = "10.3.2.0"
+}
+`,
+				ExpectError: regexp.MustCompile(`must\s+be\s+an\s+IPv4\s+or\s+IPv6\s+network`),
+			},
+		},
+	})
+}
+
+const testAccDevicesDataSourceConfig = `
+resource "firezone_user" "alice" {
+  email = "alice@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_user" "bob" {
+  email = "bob@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_wireguard_keypair" "laptop" {}
+resource "firezone_wireguard_keypair" "phone" {}
+resource "firezone_wireguard_keypair" "server" {}
+
+resource "firezone_device" "laptop" {
+  user_id    = firezone_user.alice.id
+  name       = "laptop"
+  public_key = firezone_wireguard_keypair.laptop.public_key
+  ipv4       = "10.3.2.10"
+  ipv6       = "fd00::3:2:10"
+}
+
+resource "firezone_device" "phone" {
+  user_id    = firezone_user.alice.id
+  name       = "phone"
+  public_key = firezone_wireguard_keypair.phone.public_key
+  ipv4       = "10.3.2.11"
+  ipv6       = "fd00::3:2:11"
+}
+
+resource "firezone_device" "server" {
+  user_id    = firezone_user.bob.id
+  name       = "server"
+  public_key = firezone_wireguard_keypair.server.public_key
+  ipv4       = "10.3.2.200"
+  ipv6       = "fd00::3:2:c8"
+}
+
+
+data "firezone_devices" "by_user" {
+  user_id    = firezone_user.alice.id
+  depends_on = [firezone_device.laptop, firezone_device.phone, firezone_device.server]
+}
+
+data "firezone_devices" "by_name" {
+  user_id    = firezone_user.alice.id
+  name_regex = "^ph"
+  depends_on = [firezone_device.laptop, firezone_device.phone, firezone_device.server]
+}
+
+data "firezone_devices" "by_ipv4" {
+  cidr       = "10.3.2.128/25"
+  depends_on = [firezone_device.laptop, firezone_device.phone, firezone_device.server]
+}
+
+data "firezone_devices" "by_ipv6" {
+  cidr       = "fd00::3:2:10/128"
+  depends_on = [firezone_device.laptop, firezone_device.phone, firezone_device.server]
+}
+
+data "firezone_devices" "with_keys" {
+  user_id                = firezone_user.alice.id
+  include_preshared_keys = true
+  depends_on             = [firezone_device.laptop, firezone_device.phone, firezone_device.server]
+}
+
+data "firezone_devices" "none" {
+  user_id    = firezone_user.bob.id
+  name_regex = "laptop"
+  depends_on = [firezone_device.laptop, firezone_device.phone, firezone_device.server]
+}
+`
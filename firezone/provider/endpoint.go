@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// normalizeEndpoint turns a user supplied endpoint into the base URL the
+// Firezone client expects. The scheme defaults to https, and a trailing
+// slash or /v0 API prefix is removed since the client adds it itself.
+func normalizeEndpoint(endpoint string) (string, error) {
+	endpoint = strings.TrimSpace(endpoint)
+
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q, expected http or https", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return "", errors.New("missing host")
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", errors.New("query, fragment and user info are not supported")
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.Path = strings.TrimSuffix(u.Path, "/v0")
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+
+	return u.String(), nil
+}
+
+// checkConnection makes an authenticated request to the Firezone API so
+// a wrong endpoint or API key is reported against the provider
+// configuration instead of the first resource using the client.
+func checkConnection(client *fz.Client, diags *diag.Diagnostics) {
+	_, err := client.GetConfiguration()
+
+	if err == nil {
+		return
+	}
+
+	switch code := statusCode(err); {
+	case code == http.StatusUnauthorized:
+		diags.AddAttributeError(
+			path.Root("api_key"),
+			"Invalid firezone api key",
+			"The Firezone API rejected the API key (401 Unauthorized). "+
+				"Check that the token is correct and has not been revoked.",
+		)
+	case code == http.StatusForbidden:
+		diags.AddAttributeError(
+			path.Root("api_key"),
+			"Insufficient firezone api key permissions",
+			"The Firezone API accepted the API key but denied access (403 Forbidden). "+
+				"API tokens must belong to an admin user.",
+		)
+	case code == http.StatusNotFound:
+		diags.AddAttributeError(
+			path.Root("endpoint"),
+			"Invalid firezone endpoint",
+			fmt.Sprintf("%s/v0/configuration was not found. Check that the endpoint points at the Firezone web "+
+				"application, without the API version.", client.HostURL),
+		)
+	case code != 0:
+		diags.AddAttributeError(
+			path.Root("endpoint"),
+			"Unexpected firezone API response",
+			fmt.Sprintf("Unable to read the Firezone configuration, got error: %s", err),
+		)
+	case isTLSError(err):
+		diags.AddAttributeError(
+			path.Root("endpoint"),
+			"TLS connection to firezone endpoint failed",
+			fmt.Sprintf("Unable to establish a TLS connection to %s, got error: %s\n\n"+
+				"Use ca_cert_pem or ca_cert_file to trust a private CA, "+
+				"and client_cert and client_key if the server requires a client certificate.", client.HostURL, err),
+		)
+	default:
+		diags.AddAttributeError(
+			path.Root("endpoint"),
+			"Unable to reach firezone endpoint",
+			fmt.Sprintf("Unable to connect to %s, got error: %s\n\n"+
+				"Set skip_credentials_validation to configure the provider without connecting to the API.", client.HostURL, err),
+		)
+	}
+}
+
+// isTLSError reports whether err is caused by a failed TLS handshake or
+// certificate verification, which is not resolved by retrying.
+func isTLSError(err error) bool {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+		recordHeader     tls.RecordHeaderError
+	)
+
+	if errors.As(err, &unknownAuthority) || errors.As(err, &hostname) ||
+		errors.As(err, &invalid) || errors.As(err, &recordHeader) {
+		return true
+	}
+
+	// Alerts sent by the server, e.g. for a missing client certificate,
+	// are not exported as a type.
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && strings.Contains(opErr.Err.Error(), "tls: ")
+}
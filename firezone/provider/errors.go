@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// clientErrorRegexp matches the errors the Firezone client returns for
+// unsuccessful responses.
+var clientErrorRegexp = regexp.MustCompile(`(?s)^status: (\d{3}), body: (.*)$`)
+
+// statusCode returns the HTTP status code of a Firezone client error, or 0
+// if err is not caused by an unsuccessful response.
+func statusCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	m := clientErrorRegexp.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+
+	code, _ := strconv.Atoi(m[1])
+	return code
+}
+
+// isNotFound reports whether err is a Firezone client error for a 404
+// response.
+func isNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+// fieldErrors returns the validation errors per field of a Firezone client
+// error for a 422 response, or nil for any other error.
+func fieldErrors(err error) map[string][]string {
+	if statusCode(err) != http.StatusUnprocessableEntity {
+		return nil
+	}
+
+	m := clientErrorRegexp.FindStringSubmatch(err.Error())
+
+	var body struct {
+		Errors map[string][]string `json:"errors"`
+	}
+
+	if json.Unmarshal([]byte(m[2]), &body) != nil {
+		return nil
+	}
+
+	return body.Errors
+}
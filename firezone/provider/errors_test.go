@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestStatusCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{nil, 0},
+		{errors.New("dial tcp: connection refused"), 0},
+		{fmt.Errorf("status: %d, body: %s", 404, `{"errors":{"detail":"Not Found"}}`), 404},
+		{fmt.Errorf("status: %d, body: %s", 422, `{"errors":{"email":["has invalid format"]}}`), 422},
+	}
+
+	for _, c := range cases {
+		if code := statusCode(c.err); code != c.code {
+			t.Errorf("statusCode(%v) = %d, expected %d", c.err, code, c.code)
+		}
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	cases := []struct {
+		err    error
+		errors map[string][]string
+	}{
+		{nil, nil},
+		{errors.New("dial tcp: connection refused"), nil},
+		{fmt.Errorf("status: %d, body: %s", 404, `{"errors":{"detail":"Not Found"}}`), nil},
+		{fmt.Errorf("status: %d, body: %s", 422, `not json`), nil},
+		{
+			fmt.Errorf("status: %d, body: %s", 422, `{"errors":{"email":["has invalid format","has already been taken"]}}`),
+			map[string][]string{"email": {"has invalid format", "has already been taken"}},
+		},
+	}
+
+	for _, c := range cases {
+		if errors := fieldErrors(c.err); !reflect.DeepEqual(errors, c.errors) {
+			t.Errorf("fieldErrors(%v) = %v, expected %v", c.err, errors, c.errors)
+		}
+	}
+}
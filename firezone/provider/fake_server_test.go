@@ -0,0 +1,1068 @@
+package provider
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeFirezone is an in-memory implementation of the parts of the Firezone
+// v0 REST API used by the provider. The acceptance tests run against it so
+// they do not need a live Firezone portal.
+type fakeFirezone struct {
+	apiKey string
+
+	mu            sync.Mutex
+	seq           int
+	configuration *fakeConfiguration
+	users         map[string]*fakeUser
+	devices       map[string]*fakeDevice
+	rules         map[string]*fakeRule
+
+	// responses holds canned statuses for the next request to a
+	// "METHOD /path", see respondOnce.
+	responses map[string]int
+
+	// ruleWatcher is called with the rules after every request to the
+	// rules endpoint, see watchRules.
+	ruleWatcher func(rules []fakeRule)
+}
+
+type fakeConfiguration struct {
+	ID                                   string            `json:"id"`
+	AllowUnprivilegedDeviceConfiguration bool              `json:"allow_unprivileged_device_configuration"`
+	AllowUnprivilegedDeviceManagement    bool              `json:"allow_unprivileged_device_management"`
+	DefaultClientAllowedIPs              []string          `json:"default_client_allowed_ips"`
+	DefaultClientDNS                     []string          `json:"default_client_dns"`
+	DefaultClientEndpoint                string            `json:"default_client_endpoint"`
+	DefaultClientMTU                     int               `json:"default_client_mtu"`
+	DefaultClientPersistentKeepalive     int               `json:"default_client_persistent_keepalive"`
+	DisableVPNOnOIDCError                bool              `json:"disable_vpn_on_oidc_error"`
+	LocalAuthEnabled                     bool              `json:"local_auth_enabled"`
+	Logo                                 *fakeLogo         `json:"logo"`
+	OpenIDConnectProviders               []json.RawMessage `json:"openid_connect_providers"`
+	SAMLIdentityProviders                []json.RawMessage `json:"saml_identity_providers"`
+	VPNSessionDuration                   int               `json:"vpn_session_duration"`
+	InsertedAt                           string            `json:"inserted_at"`
+	UpdatedAt                            string            `json:"updated_at"`
+}
+
+type fakeLogo struct {
+	URL  *string `json:"url"`
+	Data *string `json:"data"`
+	Type *string `json:"type"`
+}
+
+type fakeUser struct {
+	seq int
+	// password is never returned, like Firezone only stores its hash.
+	password string
+
+	ID                 string  `json:"id"`
+	Email              string  `json:"email"`
+	Role               string  `json:"role"`
+	LastSignedInAt     *string `json:"last_signed_in_at"`
+	LastSignedInMethod *string `json:"last_signed_in_method"`
+	DisabledAt         *string `json:"disabled_at"`
+	InsertedAt         string  `json:"inserted_at"`
+	UpdatedAt          string  `json:"updated_at"`
+}
+
+type fakeDevice struct {
+	seq int
+
+	ID                            string   `json:"id"`
+	UserID                        string   `json:"user_id"`
+	Name                          string   `json:"name"`
+	Description                   string   `json:"description"`
+	PublicKey                     string   `json:"public_key"`
+	PresharedKey                  string   `json:"preshared_key"`
+	ServerPublicKey               string   `json:"server_public_key"`
+	IPv4                          string   `json:"ipv4"`
+	IPv6                          string   `json:"ipv6"`
+	AllowedIPs                    []string `json:"allowed_ips"`
+	DNS                           []string `json:"dns"`
+	Endpoint                      *string  `json:"endpoint"`
+	MTU                           *int     `json:"mtu"`
+	PersistentKeepalive           *int     `json:"persistent_keepalive"`
+	UseDefaultAllowedIPs          bool     `json:"use_default_allowed_ips"`
+	UseDefaultDNS                 bool     `json:"use_default_dns"`
+	UseDefaultEndpoint            bool     `json:"use_default_endpoint"`
+	UseDefaultMTU                 bool     `json:"use_default_mtu"`
+	UseDefaultPersistentKeepalive bool     `json:"use_default_persistent_keepalive"`
+	LatestHandshake               *string  `json:"latest_handshake"`
+	RemoteIP                      *string  `json:"remote_ip"`
+	RXBytes                       *int     `json:"rx_bytes"`
+	TXBytes                       *int     `json:"tx_bytes"`
+	InsertedAt                    string   `json:"inserted_at"`
+	UpdatedAt                     string   `json:"updated_at"`
+}
+
+type fakeRule struct {
+	seq int
+
+	ID          string  `json:"id"`
+	UserID      *string `json:"user_id"`
+	Action      string  `json:"action"`
+	Destination string  `json:"destination"`
+	PortRange   *string `json:"port_range"`
+	PortType    *string `json:"port_type"`
+	InsertedAt  string  `json:"inserted_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+const fakeServerPublicKey = "Iz4TV4ArljIOpM6xexU+ZYieHm0Gf7ZmZd5INGALshU="
+
+const (
+	fakeMinPasswordLength = 12
+	fakeMaxPasswordLength = 64
+)
+
+var (
+	fakeEmailRegexp     = regexp.MustCompile(`^[^\s@]+@[^\s@]+$`)
+	fakePortRangeRegexp = regexp.MustCompile(`^\s*(\d+)\s*(?:-\s*(\d+)\s*)?$`)
+	fakeIPv4Network     = netip.MustParsePrefix("10.3.2.0/24")
+	fakeIPv6Network     = netip.MustParsePrefix("fd00::3:2:0/120")
+)
+
+func newFakeFirezone(apiKey string) *fakeFirezone {
+	return &fakeFirezone{
+		apiKey:        apiKey,
+		configuration: newFakeConfiguration(),
+		users:         map[string]*fakeUser{},
+		devices:       map[string]*fakeDevice{},
+		rules:         map[string]*fakeRule{},
+		responses:     map[string]int{},
+	}
+}
+
+// newFakeConfiguration returns the configuration of a fresh Firezone
+// installation.
+func newFakeConfiguration() *fakeConfiguration {
+	return &fakeConfiguration{
+		ID:                               fakeUUID(),
+		DefaultClientAllowedIPs:          []string{"0.0.0.0/0", "::/0"},
+		DefaultClientDNS:                 []string{"1.1.1.1", "1.0.0.1"},
+		DefaultClientEndpoint:            "vpn.example.com:51820",
+		DefaultClientMTU:                 1280,
+		DefaultClientPersistentKeepalive: 25,
+		LocalAuthEnabled:                 true,
+		OpenIDConnectProviders:           []json.RawMessage{},
+		SAMLIdentityProviders:            []json.RawMessage{},
+		InsertedAt:                       fakeNow(),
+		UpdatedAt:                        fakeNow(),
+	}
+}
+
+func (f *fakeFirezone) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+f.apiKey {
+		writeFakeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+			"errors": map[string]string{"detail": "Unauthorized"},
+		})
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "v0" {
+		writeFakeNotFound(w)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if status, ok := f.responses[r.Method+" "+r.URL.Path]; ok {
+		delete(f.responses, r.Method+" "+r.URL.Path)
+		if status == http.StatusNoContent {
+			w.WriteHeader(status)
+		} else {
+			writeFakeJSON(w, status, map[string]interface{}{
+				"errors": map[string]string{"detail": http.StatusText(status)},
+			})
+		}
+		return
+	}
+
+	switch parts[1] {
+	case "configuration":
+		f.serveConfiguration(w, r, parts[2:])
+	case "users":
+		f.serveUsers(w, r, parts[2:])
+	case "devices":
+		f.serveDevices(w, r, parts[2:])
+	case "rules":
+		f.serveRules(w, r, parts[2:])
+		if f.ruleWatcher != nil {
+			rules := make([]fakeRule, 0, len(f.rules))
+			for _, rule := range f.rules {
+				rules = append(rules, *rule)
+			}
+			f.ruleWatcher(rules)
+		}
+	default:
+		writeFakeNotFound(w)
+	}
+}
+
+// user, device and rule look objects up for test assertions.
+
+func (f *fakeFirezone) user(id string) *fakeUser {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.users[id]
+}
+
+// userPassword returns the password of user id for test assertions.
+func (f *fakeFirezone) userPassword(id string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if u, ok := f.users[id]; ok {
+		return u.password
+	}
+	return ""
+}
+
+func (f *fakeFirezone) device(id string) *fakeDevice {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.devices[id]
+}
+
+func (f *fakeFirezone) rule(id string) *fakeRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rules[id]
+}
+
+// signInUser, disableUser and changeUserPassword change a user the way
+// signing in or an administrator would, outside of the API.
+
+func (f *fakeFirezone) signInUser(email string, method string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if u := f.findUser(email); u != nil {
+		now := fakeNow()
+		u.LastSignedInAt, u.LastSignedInMethod = &now, &method
+	}
+}
+
+func (f *fakeFirezone) disableUser(email string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if u := f.findUser(email); u != nil {
+		now := fakeNow()
+		u.DisabledAt = &now
+	}
+}
+
+func (f *fakeFirezone) changeUserPassword(email string, password string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if u := f.findUser(email); u != nil {
+		u.password = password
+	}
+}
+
+// duplicateDevice copies the device named name, as left behind by Firezone
+// versions which did not enforce unique device names.
+func (f *fakeFirezone) duplicateDevice(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, d := range f.devices {
+		if d.Name == name {
+			duplicate := *d
+			duplicate.ID, duplicate.seq = fakeUUID(), f.nextSeq()
+			f.devices[duplicate.ID] = &duplicate
+			return
+		}
+	}
+}
+
+// respondOnce makes the next request to method and path return status
+// without doing anything, to simulate failures and misbehaving servers.
+func (f *fakeFirezone) respondOnce(method string, path string, status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[method+" "+path] = status
+}
+
+// resetConfiguration restores the default configuration, since removing a
+// firezone_configuration resource leaves the configuration as it is.
+func (f *fakeFirezone) resetConfiguration() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.configuration = newFakeConfiguration()
+}
+
+// updateConfig changes the configuration behind the provider's back.
+func (f *fakeFirezone) updateConfig(update func(c *fakeConfiguration)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	update(f.configuration)
+}
+
+// config returns a copy of the configuration for test assertions.
+func (f *fakeFirezone) config() fakeConfiguration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.configuration
+}
+
+// deleteUser, deleteDevice and deleteRule remove objects behind the
+// provider's back, like an administrator using the Firezone UI.
+
+func (f *fakeFirezone) deleteUser(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removeUser(id)
+}
+
+func (f *fakeFirezone) deleteDevice(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.devices, id)
+}
+
+func (f *fakeFirezone) deleteRule(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.rules, id)
+}
+
+// addRule creates a global rule behind the provider's back and returns its
+// id.
+func (f *fakeFirezone) addRule(action string, destination string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rule := &fakeRule{
+		ID:          fakeUUID(),
+		Action:      action,
+		Destination: destination,
+		InsertedAt:  fakeNow(),
+		UpdatedAt:   fakeNow(),
+		seq:         f.nextSeq(),
+	}
+	f.rules[rule.ID] = rule
+	return rule.ID
+}
+
+// ruleCount returns the number of rules of a user, or of global rules when
+// userID is empty.
+func (f *fakeFirezone) ruleCount(userID string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, rule := range f.rules {
+		if (rule.UserID == nil && userID == "") || (rule.UserID != nil && *rule.UserID == userID) {
+			n++
+		}
+	}
+	return n
+}
+
+// watchRules calls watch with all rules after every request to the rules
+// endpoint, to check what the rules look like while they are changed. A nil
+// watch stops watching.
+func (f *fakeFirezone) watchRules(watch func(rules []fakeRule)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ruleWatcher = watch
+}
+
+// removeUser deletes a user together with its devices and rules.
+func (f *fakeFirezone) removeUser(id string) {
+	delete(f.users, id)
+	for deviceID, d := range f.devices {
+		if d.UserID == id {
+			delete(f.devices, deviceID)
+		}
+	}
+	for ruleID, rule := range f.rules {
+		if rule.UserID != nil && *rule.UserID == id {
+			delete(f.rules, ruleID)
+		}
+	}
+}
+
+func (f *fakeFirezone) serveConfiguration(w http.ResponseWriter, r *http.Request, rest []string) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		writeFakeData(w, http.StatusOK, f.configuration)
+
+	case len(rest) == 0 && (r.Method == http.MethodPatch || r.Method == http.MethodPut):
+		params, ok := readFakeParams(w, r, "configuration")
+		if !ok {
+			return
+		}
+		updated := *f.configuration
+		if errs := applyConfiguration(&updated, params); len(errs) > 0 {
+			writeFakeErrors(w, errs)
+			return
+		}
+		updated.UpdatedAt = fakeNow()
+		*f.configuration = updated
+		writeFakeData(w, http.StatusOK, f.configuration)
+
+	default:
+		writeFakeNotFound(w)
+	}
+}
+
+func applyConfiguration(c *fakeConfiguration, params fakeParams) map[string][]string {
+	errs := map[string][]string{}
+
+	params.decode("allow_unprivileged_device_configuration", &c.AllowUnprivilegedDeviceConfiguration)
+	params.decode("allow_unprivileged_device_management", &c.AllowUnprivilegedDeviceManagement)
+	params.decode("default_client_allowed_ips", &c.DefaultClientAllowedIPs)
+	params.decode("default_client_dns", &c.DefaultClientDNS)
+	params.decode("default_client_endpoint", &c.DefaultClientEndpoint)
+	params.decode("default_client_mtu", &c.DefaultClientMTU)
+	params.decode("default_client_persistent_keepalive", &c.DefaultClientPersistentKeepalive)
+	params.decode("disable_vpn_on_oidc_error", &c.DisableVPNOnOIDCError)
+	params.decode("local_auth_enabled", &c.LocalAuthEnabled)
+	params.decode("vpn_session_duration", &c.VPNSessionDuration)
+
+	if _, ok := params["openid_connect_providers"]; ok {
+		var providers []json.RawMessage
+		params.decode("openid_connect_providers", &providers)
+		c.OpenIDConnectProviders = applyFakeIdentityProviders(providers, errs, "openid_connect_providers",
+			[]string{"id", "label", "client_id", "client_secret", "discovery_document_uri"},
+			map[string]interface{}{"scope": "openid email profile", "response_type": "code", "auto_create_users": false, "redirect_uri": nil})
+	}
+
+	if _, ok := params["saml_identity_providers"]; ok {
+		var providers []json.RawMessage
+		params.decode("saml_identity_providers", &providers)
+		c.SAMLIdentityProviders = applyFakeIdentityProviders(providers, errs, "saml_identity_providers",
+			[]string{"id", "label", "metadata"},
+			map[string]interface{}{"base_url": "https://firezone.example.com/auth/saml", "sign_requests": true, "sign_metadata": true,
+				"signed_assertion_in_resp": true, "signed_envelopes_in_resp": true, "auto_create_users": false})
+	}
+
+	if _, ok := params["logo"]; ok {
+		c.Logo = nil
+		params.decode("logo", &c.Logo)
+	}
+
+	for _, ip := range c.DefaultClientAllowedIPs {
+		if _, ok := parseFakeInet(ip); !ok {
+			errs["default_client_allowed_ips"] = append(errs["default_client_allowed_ips"], "is invalid")
+		}
+	}
+
+	for _, ip := range c.DefaultClientDNS {
+		if _, err := netip.ParseAddr(ip); err != nil {
+			errs["default_client_dns"] = append(errs["default_client_dns"], "is invalid")
+		}
+	}
+
+	if c.DefaultClientMTU < 576 || c.DefaultClientMTU > 1500 {
+		errs["default_client_mtu"] = append(errs["default_client_mtu"], "must be between 576 and 1500")
+	}
+
+	if c.DefaultClientPersistentKeepalive < 0 || c.DefaultClientPersistentKeepalive > 120 {
+		errs["default_client_persistent_keepalive"] = append(errs["default_client_persistent_keepalive"], "must be between 0 and 120")
+	}
+
+	if c.VPNSessionDuration < 0 {
+		errs["vpn_session_duration"] = append(errs["vpn_session_duration"], "must be greater than or equal to 0")
+	}
+
+	if c.Logo != nil && c.Logo.URL == nil && c.Logo.Data == nil {
+		c.Logo = nil
+	}
+
+	return errs
+}
+
+// applyFakeIdentityProviders validates an identity provider list, adding
+// errors for missing required fields and duplicate ids, and fills in the
+// defaults of missing optional fields.
+func applyFakeIdentityProviders(providers []json.RawMessage, errs map[string][]string, field string, required []string, defaults map[string]interface{}) []json.RawMessage {
+	result := make([]json.RawMessage, 0, len(providers))
+	ids := map[string]bool{}
+
+	for _, raw := range providers {
+		var provider map[string]interface{}
+		if err := json.Unmarshal(raw, &provider); err != nil {
+			errs[field] = append(errs[field], "is invalid")
+			continue
+		}
+
+		for _, key := range required {
+			if value, _ := provider[key].(string); value == "" {
+				errs[field] = append(errs[field], key+" can't be blank")
+			}
+		}
+
+		id, _ := provider["id"].(string)
+		if ids[id] {
+			errs[field] = append(errs[field], "id "+id+" has already been taken")
+		}
+		ids[id] = true
+
+		for key, value := range defaults {
+			if _, ok := provider[key]; !ok {
+				provider[key] = value
+			}
+		}
+
+		encoded, _ := json.Marshal(provider)
+		result = append(result, encoded)
+	}
+
+	return result
+}
+
+func (f *fakeFirezone) serveUsers(w http.ResponseWriter, r *http.Request, rest []string) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		users := make([]*fakeUser, 0, len(f.users))
+		for _, u := range f.users {
+			users = append(users, u)
+		}
+		sort.Slice(users, func(i, j int) bool { return users[i].seq < users[j].seq })
+		writeFakeData(w, http.StatusOK, users)
+
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		params, ok := readFakeParams(w, r, "user")
+		if !ok {
+			return
+		}
+		u := &fakeUser{Role: "unprivileged"}
+		if errs := f.applyUser(u, params); len(errs) > 0 {
+			writeFakeErrors(w, errs)
+			return
+		}
+		u.ID, u.InsertedAt, u.UpdatedAt = fakeUUID(), fakeNow(), fakeNow()
+		u.seq = f.nextSeq()
+		f.users[u.ID] = u
+		writeFakeData(w, http.StatusCreated, u)
+
+	case len(rest) == 1:
+		u := f.findUser(rest[0])
+		if u == nil {
+			writeFakeNotFound(w)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeFakeData(w, http.StatusOK, u)
+		case http.MethodPatch, http.MethodPut:
+			params, ok := readFakeParams(w, r, "user")
+			if !ok {
+				return
+			}
+			updated := *u
+			if errs := f.applyUser(&updated, params); len(errs) > 0 {
+				writeFakeErrors(w, errs)
+				return
+			}
+			updated.UpdatedAt = fakeNow()
+			*u = updated
+			writeFakeData(w, http.StatusOK, u)
+		case http.MethodDelete:
+			f.removeUser(u.ID)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeFakeNotFound(w)
+		}
+
+	case len(rest) == 2 && r.Method == http.MethodPost && (rest[1] == "disable" || rest[1] == "enable"):
+		u := f.findUser(rest[0])
+		if u == nil {
+			writeFakeNotFound(w)
+			return
+		}
+		if rest[1] == "enable" {
+			u.DisabledAt = nil
+		} else if u.DisabledAt == nil {
+			now := fakeNow()
+			u.DisabledAt = &now
+		}
+		u.UpdatedAt = fakeNow()
+		writeFakeData(w, http.StatusOK, u)
+
+	default:
+		writeFakeNotFound(w)
+	}
+}
+
+// findUser resolves a user by id or, like Firezone, by email.
+func (f *fakeFirezone) findUser(key string) *fakeUser {
+	if u, ok := f.users[key]; ok {
+		return u
+	}
+	for _, u := range f.users {
+		if strings.EqualFold(u.Email, key) {
+			return u
+		}
+	}
+	return nil
+}
+
+func (f *fakeFirezone) applyUser(u *fakeUser, params fakeParams) map[string][]string {
+	errs := map[string][]string{}
+
+	params.decode("email", &u.Email)
+	params.decode("role", &u.Role)
+
+	// Firezone stores emails in lower case.
+	u.Email = strings.ToLower(u.Email)
+
+	if u.Email == "" {
+		errs["email"] = append(errs["email"], "can't be blank")
+	} else if !fakeEmailRegexp.MatchString(u.Email) {
+		errs["email"] = append(errs["email"], "has invalid format")
+	} else if other := f.findUser(u.Email); other != nil && other.ID != u.ID {
+		errs["email"] = append(errs["email"], "has already been taken")
+	}
+
+	if u.Role != "admin" && u.Role != "unprivileged" {
+		errs["role"] = append(errs["role"], "is invalid")
+	}
+
+	if _, ok := params["password"]; ok {
+		var password, confirmation string
+		params.decode("password", &password)
+		params.decode("password_confirmation", &confirmation)
+
+		if len(password) < fakeMinPasswordLength || len(password) > fakeMaxPasswordLength {
+			errs["password"] = append(errs["password"], fmt.Sprintf("should be between %d and %d characters", fakeMinPasswordLength, fakeMaxPasswordLength))
+		} else if password != confirmation {
+			errs["password_confirmation"] = append(errs["password_confirmation"], "does not match password")
+		} else {
+			u.password = password
+		}
+	}
+
+	return errs
+}
+
+func (f *fakeFirezone) serveDevices(w http.ResponseWriter, r *http.Request, rest []string) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		devices := make([]*fakeDevice, 0, len(f.devices))
+		for _, d := range f.devices {
+			devices = append(devices, d)
+		}
+		sort.Slice(devices, func(i, j int) bool { return devices[i].seq < devices[j].seq })
+		writeFakeData(w, http.StatusOK, devices)
+
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		params, ok := readFakeParams(w, r, "device")
+		if !ok {
+			return
+		}
+		d := &fakeDevice{
+			ServerPublicKey:               fakeServerPublicKey,
+			UseDefaultAllowedIPs:          true,
+			UseDefaultDNS:                 true,
+			UseDefaultEndpoint:            true,
+			UseDefaultMTU:                 true,
+			UseDefaultPersistentKeepalive: true,
+		}
+		params.decode("user_id", &d.UserID)
+		if errs := f.applyDevice(d, params); len(errs) > 0 {
+			writeFakeErrors(w, errs)
+			return
+		}
+		d.ID, d.InsertedAt, d.UpdatedAt = fakeUUID(), fakeNow(), fakeNow()
+		d.seq = f.nextSeq()
+		f.devices[d.ID] = d
+		writeFakeData(w, http.StatusCreated, d)
+
+	case len(rest) == 1:
+		d, ok := f.devices[rest[0]]
+		if !ok {
+			writeFakeNotFound(w)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeFakeData(w, http.StatusOK, d)
+		case http.MethodPatch, http.MethodPut:
+			params, ok := readFakeParams(w, r, "device")
+			if !ok {
+				return
+			}
+			updated := *d
+			if errs := f.applyDevice(&updated, params); len(errs) > 0 {
+				writeFakeErrors(w, errs)
+				return
+			}
+			updated.UpdatedAt = fakeNow()
+			*d = updated
+			writeFakeData(w, http.StatusOK, d)
+		case http.MethodDelete:
+			delete(f.devices, d.ID)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeFakeNotFound(w)
+		}
+
+	default:
+		writeFakeNotFound(w)
+	}
+}
+
+func (f *fakeFirezone) applyDevice(d *fakeDevice, params fakeParams) map[string][]string {
+	errs := map[string][]string{}
+
+	params.decode("name", &d.Name)
+	params.decode("description", &d.Description)
+	params.decode("public_key", &d.PublicKey)
+	params.decode("preshared_key", &d.PresharedKey)
+	params.decode("ipv4", &d.IPv4)
+	params.decode("ipv6", &d.IPv6)
+	params.decode("allowed_ips", &d.AllowedIPs)
+	params.decode("dns", &d.DNS)
+	params.decode("endpoint", &d.Endpoint)
+	params.decode("mtu", &d.MTU)
+	params.decode("persistent_keepalive", &d.PersistentKeepalive)
+	params.decode("use_default_allowed_ips", &d.UseDefaultAllowedIPs)
+	params.decode("use_default_dns", &d.UseDefaultDNS)
+	params.decode("use_default_endpoint", &d.UseDefaultEndpoint)
+	params.decode("use_default_mtu", &d.UseDefaultMTU)
+	params.decode("use_default_persistent_keepalive", &d.UseDefaultPersistentKeepalive)
+
+	// Firezone stores empty optional fields as NULL.
+	if d.Endpoint != nil && *d.Endpoint == "" {
+		d.Endpoint = nil
+	}
+	if d.MTU != nil && *d.MTU == 0 {
+		d.MTU = nil
+	}
+	if d.PersistentKeepalive != nil && *d.PersistentKeepalive == 0 {
+		d.PersistentKeepalive = nil
+	}
+
+	if _, ok := f.users[d.UserID]; !ok {
+		errs["user_id"] = append(errs["user_id"], "does not exist")
+	}
+
+	if d.Name == "" {
+		errs["name"] = append(errs["name"], "can't be blank")
+	}
+
+	if !isFakeKey(d.PublicKey) {
+		errs["public_key"] = append(errs["public_key"], "is invalid")
+	}
+
+	if d.PresharedKey != "" && !isFakeKey(d.PresharedKey) {
+		errs["preshared_key"] = append(errs["preshared_key"], "is invalid")
+	}
+
+	for _, other := range f.devices {
+		if other.ID == d.ID {
+			continue
+		}
+		if other.UserID == d.UserID && other.Name == d.Name {
+			errs["name"] = append(errs["name"], "has already been taken")
+		}
+		if other.PublicKey == d.PublicKey {
+			errs["public_key"] = append(errs["public_key"], "has already been taken")
+		}
+		if d.IPv4 != "" && other.IPv4 == d.IPv4 {
+			errs["ipv4"] = append(errs["ipv4"], "has already been taken")
+		}
+		if d.IPv6 != "" && other.IPv6 == d.IPv6 {
+			errs["ipv6"] = append(errs["ipv6"], "has already been taken")
+		}
+	}
+
+	if d.IPv4 != "" {
+		if addr, err := netip.ParseAddr(d.IPv4); err != nil || !fakeIPv4Network.Contains(addr) {
+			errs["ipv4"] = append(errs["ipv4"], "is not in the network range "+fakeIPv4Network.String())
+		}
+	}
+
+	if d.IPv6 != "" {
+		if addr, err := netip.ParseAddr(d.IPv6); err != nil || !fakeIPv6Network.Contains(addr) {
+			errs["ipv6"] = append(errs["ipv6"], "is not in the network range "+fakeIPv6Network.String())
+		}
+	}
+
+	for _, ip := range d.AllowedIPs {
+		if _, ok := parseFakeInet(ip); !ok {
+			errs["allowed_ips"] = append(errs["allowed_ips"], fmt.Sprintf("%s is invalid", ip))
+		}
+	}
+
+	for _, ip := range d.DNS {
+		if _, err := netip.ParseAddr(ip); err != nil {
+			errs["dns"] = append(errs["dns"], fmt.Sprintf("%s is invalid", ip))
+		}
+	}
+
+	if d.MTU != nil && (*d.MTU < 576 || *d.MTU > 1500) {
+		errs["mtu"] = append(errs["mtu"], "must be between 576 and 1500")
+	}
+
+	if d.PersistentKeepalive != nil && (*d.PersistentKeepalive < 0 || *d.PersistentKeepalive > 120) {
+		errs["persistent_keepalive"] = append(errs["persistent_keepalive"], "must be between 0 and 120")
+	}
+
+	// Like Firezone, fill in anything left blank with generated values.
+	if d.PresharedKey == "" {
+		d.PresharedKey = fakeKey()
+	}
+	if d.IPv4 == "" {
+		d.IPv4 = f.allocateAddress(d.ID, fakeIPv4Network, func(d *fakeDevice) string { return d.IPv4 })
+	}
+	if d.IPv6 == "" {
+		d.IPv6 = f.allocateAddress(d.ID, fakeIPv6Network, func(d *fakeDevice) string { return d.IPv6 })
+	}
+
+	return errs
+}
+
+// allocateAddress returns the first host address in network not used by a
+// device other than id, skipping the network and the server's own address.
+func (f *fakeFirezone) allocateAddress(id string, network netip.Prefix, current func(*fakeDevice) string) string {
+	used := map[string]bool{}
+	for _, d := range f.devices {
+		if d.ID != id {
+			used[current(d)] = true
+		}
+	}
+
+	addr := network.Addr().Next().Next()
+	for network.Contains(addr) && used[addr.String()] {
+		addr = addr.Next()
+	}
+	return addr.String()
+}
+
+func (f *fakeFirezone) serveRules(w http.ResponseWriter, r *http.Request, rest []string) {
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		rules := make([]*fakeRule, 0, len(f.rules))
+		for _, rule := range f.rules {
+			rules = append(rules, rule)
+		}
+		sort.Slice(rules, func(i, j int) bool { return rules[i].seq < rules[j].seq })
+		writeFakeData(w, http.StatusOK, rules)
+
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		params, ok := readFakeParams(w, r, "rule")
+		if !ok {
+			return
+		}
+		rule := &fakeRule{}
+		if errs := f.applyRule(rule, params); len(errs) > 0 {
+			writeFakeErrors(w, errs)
+			return
+		}
+		rule.ID, rule.InsertedAt, rule.UpdatedAt = fakeUUID(), fakeNow(), fakeNow()
+		rule.seq = f.nextSeq()
+		f.rules[rule.ID] = rule
+		writeFakeData(w, http.StatusCreated, rule)
+
+	case len(rest) == 1:
+		rule, ok := f.rules[rest[0]]
+		if !ok {
+			writeFakeNotFound(w)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeFakeData(w, http.StatusOK, rule)
+		case http.MethodPatch, http.MethodPut:
+			params, ok := readFakeParams(w, r, "rule")
+			if !ok {
+				return
+			}
+			updated := *rule
+			if errs := f.applyRule(&updated, params); len(errs) > 0 {
+				writeFakeErrors(w, errs)
+				return
+			}
+			updated.UpdatedAt = fakeNow()
+			*rule = updated
+			writeFakeData(w, http.StatusOK, rule)
+		case http.MethodDelete:
+			delete(f.rules, rule.ID)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeFakeNotFound(w)
+		}
+
+	default:
+		writeFakeNotFound(w)
+	}
+}
+
+func (f *fakeFirezone) applyRule(rule *fakeRule, params fakeParams) map[string][]string {
+	errs := map[string][]string{}
+
+	params.decode("user_id", &rule.UserID)
+	params.decode("action", &rule.Action)
+	params.decode("destination", &rule.Destination)
+	params.decode("port_range", &rule.PortRange)
+	params.decode("port_type", &rule.PortType)
+
+	// The client sends empty strings for unset optional fields.
+	for _, field := range []**string{&rule.UserID, &rule.PortRange, &rule.PortType} {
+		if *field != nil && **field == "" {
+			*field = nil
+		}
+	}
+
+	if rule.UserID != nil {
+		if _, ok := f.users[*rule.UserID]; !ok {
+			errs["user_id"] = append(errs["user_id"], "does not exist")
+		}
+	}
+
+	if rule.Action != "accept" && rule.Action != "drop" {
+		errs["action"] = append(errs["action"], "is invalid")
+	}
+
+	if prefix, ok := parseFakeInet(rule.Destination); ok {
+		rule.Destination = prefix.String()
+	} else {
+		errs["destination"] = append(errs["destination"], "is invalid")
+	}
+
+	if rule.PortType != nil && *rule.PortType != "tcp" && *rule.PortType != "udp" {
+		errs["port_type"] = append(errs["port_type"], "is invalid")
+	}
+
+	if rule.PortRange != nil {
+		if canonical, ok := parseFakePortRange(*rule.PortRange); ok {
+			rule.PortRange = &canonical
+		} else {
+			errs["port_range"] = append(errs["port_range"], "is invalid")
+		}
+	}
+
+	if (rule.PortRange == nil) != (rule.PortType == nil) {
+		errs["port_type"] = append(errs["port_type"], "port_type and port_range must be set together")
+	}
+
+	return errs
+}
+
+func (f *fakeFirezone) nextSeq() int {
+	f.seq++
+	return f.seq
+}
+
+// fakeParams holds the decoded attributes of a create or update request.
+type fakeParams map[string]json.RawMessage
+
+// decode unmarshals key into dst if the request contained it.
+func (p fakeParams) decode(key string, dst interface{}) {
+	if raw, ok := p[key]; ok {
+		_ = json.Unmarshal(raw, dst)
+	}
+}
+
+// readFakeParams decodes a request body. Like Firezone, attributes must be
+// wrapped in an object named after the resource.
+func readFakeParams(w http.ResponseWriter, r *http.Request, wrapper string) (fakeParams, bool) {
+	var body map[string]json.RawMessage
+	var params fakeParams
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || json.Unmarshal(body[wrapper], &params) != nil || params == nil {
+		writeFakeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"errors": map[string]string{"detail": "Bad Request"},
+		})
+		return nil, false
+	}
+
+	return params, true
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeFakeData(w http.ResponseWriter, status int, data interface{}) {
+	writeFakeJSON(w, status, map[string]interface{}{"data": data})
+}
+
+func writeFakeErrors(w http.ResponseWriter, errs map[string][]string) {
+	writeFakeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"errors": errs})
+}
+
+func writeFakeNotFound(w http.ResponseWriter) {
+	writeFakeJSON(w, http.StatusNotFound, map[string]interface{}{
+		"errors": map[string]string{"detail": "Not Found"},
+	})
+}
+
+// parseFakeInet parses an address or network the way Firezone's inet
+// columns do: bare addresses become host routes and host bits are masked.
+func parseFakeInet(s string) (netip.Prefix, bool) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked(), true
+	}
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), true
+	}
+	return netip.Prefix{}, false
+}
+
+// parseFakePortRange accepts "N", "N-M" and "N - M" and returns the range in
+// Firezone's canonical form.
+func parseFakePortRange(s string) (string, bool) {
+	m := fakePortRangeRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+
+	start, err := strconv.Atoi(m[1])
+	if err != nil {
+		return "", false
+	}
+	end := start
+	if m[2] != "" {
+		if end, err = strconv.Atoi(m[2]); err != nil {
+			return "", false
+		}
+	}
+
+	if start < 1 || end > 65535 || start > end {
+		return "", false
+	}
+	if start == end {
+		return strconv.Itoa(start), true
+	}
+	return fmt.Sprintf("%d - %d", start, end), true
+}
+
+func isFakeKey(s string) bool {
+	key, err := base64.StdEncoding.DecodeString(s)
+	return err == nil && len(key) == 32
+}
+
+func fakeKey() string {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func fakeUUID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func fakeNow() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000000Z")
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+const (
+	oidcProvidersField = "openid_connect_providers"
+	samlProvidersField = "saml_identity_providers"
+)
+
+// identityProvidersMu serialises changes to the identity provider lists.
+// Each list is stored in the single configuration object and replaced as a
+// whole, so concurrent changes to different providers would overwrite each
+// other.
+var identityProvidersMu sync.Mutex
+
+// identityProviders returns the raw entries of the identity provider list
+// field. Entries are kept raw so fields the provider does not know about
+// survive an update of another entry.
+func (c *firezoneConfiguration) identityProviders(field string) []json.RawMessage {
+	if field == samlProvidersField {
+		return c.SAMLIdentityProviders
+	}
+	return c.OpenIDConnectProviders
+}
+
+// findIdentityProvider decodes the entry with id into out and reports
+// whether it exists.
+func findIdentityProvider(entries []json.RawMessage, id string, out interface{}) (bool, error) {
+	for _, raw := range entries {
+		if identityProviderID(raw) != id {
+			continue
+		}
+		return true, json.Unmarshal(raw, out)
+	}
+	return false, nil
+}
+
+// modifyIdentityProviders replaces the identity provider list field with
+// the result of modify, which is given the current entries.
+func modifyIdentityProviders(client *fz.Client, field string, modify func([]json.RawMessage) ([]json.RawMessage, error)) (*firezoneConfiguration, error) {
+	identityProvidersMu.Lock()
+	defer identityProvidersMu.Unlock()
+
+	configuration, err := getConfiguration(client)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := modify(configuration.identityProviders(field))
+	if err != nil {
+		return nil, err
+	}
+
+	return updateConfiguration(client, map[string]interface{}{field: entries})
+}
+
+// putIdentityProvider sets the fields of entry on the entry with id, or
+// appends entry when there is none. Fields of the existing entry which entry
+// does not have are kept.
+func putIdentityProvider(entries []json.RawMessage, id string, entry interface{}) ([]json.RawMessage, error) {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	result := make([]json.RawMessage, 0, len(entries)+1)
+	replaced := false
+
+	for _, e := range entries {
+		if identityProviderID(e) != id {
+			result = append(result, e)
+			continue
+		}
+
+		var existing map[string]json.RawMessage
+		if err := json.Unmarshal(e, &existing); err != nil || existing == nil {
+			existing = map[string]json.RawMessage{}
+		}
+
+		for key, value := range fields {
+			existing[key] = value
+		}
+
+		merged, err := json.Marshal(existing)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, merged)
+		replaced = true
+	}
+
+	if !replaced {
+		result = append(result, raw)
+	}
+
+	return result, nil
+}
+
+// removeIdentityProvider returns entries without the entry with id.
+func removeIdentityProvider(entries []json.RawMessage, id string) []json.RawMessage {
+	result := make([]json.RawMessage, 0, len(entries))
+
+	for _, e := range entries {
+		if identityProviderID(e) != id {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+// errIdentityProviderExists is returned when creating an identity provider
+// with an id which is already taken.
+func errIdentityProviderExists(id string) error {
+	return fmt.Errorf("an identity provider with id %q already exists, import it instead", id)
+}
+
+func identityProviderID(raw json.RawMessage) string {
+	var entry struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(raw, &entry)
+	return entry.ID
+}
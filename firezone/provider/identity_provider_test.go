@@ -0,0 +1,43 @@
+package provider
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPutIdentityProvider(t *testing.T) {
+	entries := []json.RawMessage{
+		json.RawMessage(`{"id":"google","label":"Google"}`),
+		json.RawMessage(`{"id":"okta","label":"Okta","scope":"openid","extra":"kept"}`),
+	}
+
+	result, err := putIdentityProvider(entries, "okta", map[string]interface{}{"id": "okta", "label": "Okta SSO"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result) != 2 || string(result[0]) != string(entries[0]) {
+		t.Fatalf("expected the other entry to be left alone, got %s", result)
+	}
+
+	var okta map[string]string
+	if err := json.Unmarshal(result[1], &okta); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"id": "okta", "label": "Okta SSO", "scope": "openid", "extra": "kept"}
+	for key, value := range expected {
+		if okta[key] != value {
+			t.Errorf("expected %s to be %q, got %q", key, value, okta[key])
+		}
+	}
+
+	result, err = putIdentityProvider(result, "azure", map[string]interface{}{"id": "azure"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result) != 3 || identityProviderID(result[2]) != "azure" {
+		t.Errorf("expected azure to be appended, got %s", result)
+	}
+}
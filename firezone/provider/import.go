@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// uuidRegexp matches the identifiers Firezone assigns, which are imported
+// as they are.
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// isUUID reports whether importID is a Firezone identifier.
+func isUUID(importID string) bool {
+	return uuidRegexp.MatchString(importID)
+}
+
+// isWireGuardKey reports whether importID is a base64 encoded WireGuard key.
+func isWireGuardKey(importID string) bool {
+	key, err := base64.StdEncoding.DecodeString(importID)
+	return err == nil && len(key) == 32
+}
+
+// importMatch returns the id of the only object matching importID, given
+// the ids of all matching objects. For none or several it adds an error
+// and returns false.
+func importMatch(diags *diag.Diagnostics, kind string, importID string, ids []string) (string, bool) {
+	switch len(ids) {
+	case 0:
+		diags.AddError(
+			"Import Identifier Not Found",
+			fmt.Sprintf("No %s matches the import identifier %q.", kind, importID),
+		)
+		return "", false
+	case 1:
+		return ids[0], true
+	default:
+		diags.AddError(
+			"Ambiguous Import Identifier",
+			fmt.Sprintf("%d %ss match the import identifier %q, with ids %s. Import the %s by id instead.",
+				len(ids), kind, importID, strings.Join(ids, ", "), kind),
+		)
+		return "", false
+	}
+}
+
+// importUserID resolves the user part of an import identifier, either a
+// user id or an email, to the user id.
+func importUserID(client *fz.Client, diags *diag.Diagnostics, user string) (string, bool) {
+	if isUUID(user) {
+		return user, true
+	}
+
+	users, err := client.GetAllUsers()
+
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to read users, got error: %s", err))
+		return "", false
+	}
+
+	var ids []string
+
+	for _, u := range *users {
+		if strings.EqualFold(u.Email, user) {
+			ids = append(ids, u.ID)
+		}
+	}
+
+	return importMatch(diags, "user", user, ids)
+}
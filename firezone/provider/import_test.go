@@ -0,0 +1,28 @@
+package provider
+
+import "testing"
+
+func TestImportIdentifierKinds(t *testing.T) {
+	cases := []struct {
+		importID     string
+		uuid         bool
+		wireGuardKey bool
+	}{
+		{"7b4f5c2e-4a8e-4d3b-9f0a-2e6c1d8b5a90", true, false},
+		{"7B4F5C2E-4A8E-4D3B-9F0A-2E6C1D8B5A90", true, false},
+		{"+5l6DkeC7EjO/k+KxmKR3altmfCvjHrSOB240ccmBCg=", false, true},
+		{"root@example.com", false, false},
+		{"root@example.com/laptop", false, false},
+		{"7b4f5c2e-4a8e-4d3b-9f0a-2e6c1d8b5a90/laptop", false, false},
+		{"/drop/172.16.0.0/12/tcp/22", false, false},
+	}
+
+	for _, c := range cases {
+		if uuid := isUUID(c.importID); uuid != c.uuid {
+			t.Errorf("isUUID(%q) = %t, expected %t", c.importID, uuid, c.uuid)
+		}
+		if key := isWireGuardKey(c.importID); key != c.wireGuardKey {
+			t.Errorf("isWireGuardKey(%q) = %t, expected %t", c.importID, key, c.wireGuardKey)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &OIDCProviderResource{}
+var _ resource.ResourceWithImportState = &OIDCProviderResource{}
+
+// identityProviderIDRegexp matches the ids Firezone accepts for identity
+// providers, which become part of their sign in URLs.
+var identityProviderIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func NewOIDCProviderResource() resource.Resource {
+	return &OIDCProviderResource{}
+}
+
+// OIDCProviderResource defines the resource implementation.
+type OIDCProviderResource struct {
+	client *fz.Client
+}
+
+// OIDCProviderResourceModel describes the resource data model.
+type OIDCProviderResourceModel struct {
+	Id                   types.String `tfsdk:"id"`
+	Label                types.String `tfsdk:"label"`
+	ClientId             types.String `tfsdk:"client_id"`
+	ClientSecret         types.String `tfsdk:"client_secret"`
+	DiscoveryDocumentURI types.String `tfsdk:"discovery_document_uri"`
+	RedirectURI          types.String `tfsdk:"redirect_uri"`
+	ResponseType         types.String `tfsdk:"response_type"`
+	Scope                types.String `tfsdk:"scope"`
+	AutoCreateUsers      types.Bool   `tfsdk:"auto_create_users"`
+}
+
+// firezoneOIDCProvider is an OpenID Connect provider entry of the Firezone
+// configuration.
+type firezoneOIDCProvider struct {
+	ID                   string  `json:"id"`
+	Label                string  `json:"label"`
+	ClientID             string  `json:"client_id"`
+	ClientSecret         string  `json:"client_secret"`
+	DiscoveryDocumentURI string  `json:"discovery_document_uri"`
+	RedirectURI          *string `json:"redirect_uri"`
+	ResponseType         string  `json:"response_type"`
+	Scope                string  `json:"scope"`
+	AutoCreateUsers      bool    `json:"auto_create_users"`
+}
+
+func (r *OIDCProviderResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_oidc_provider"
+}
+
+func (r *OIDCProviderResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "OpenID Connect identity provider. Other identity providers in the Firezone configuration are left as they are.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "OIDC provider identifier, used in the sign in URL `/auth/oidc/<id>`",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(
+						identityProviderIDRegexp,
+						"must only contain letters, digits, '_' and '-'",
+					),
+				},
+			},
+			"label": schema.StringAttribute{
+				MarkdownDescription: "OIDC provider label shown on the sign in page",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"client_id": schema.StringAttribute{
+				MarkdownDescription: "OIDC client id",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"client_secret": schema.StringAttribute{
+				MarkdownDescription: "OIDC client secret",
+				Required:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"discovery_document_uri": schema.StringAttribute{
+				MarkdownDescription: "OIDC discovery document URL, usually ending with `/.well-known/openid-configuration`",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(
+						regexp.MustCompile(`^https?://`),
+						"must be an http or https URL",
+					),
+				},
+			},
+			"redirect_uri": schema.StringAttribute{
+				MarkdownDescription: "OIDC redirect URL, defaults to the one derived from the Firezone URL",
+				Optional:            true,
+			},
+			"response_type": schema.StringAttribute{
+				MarkdownDescription: "OIDC response type",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("code"),
+			},
+			"scope": schema.StringAttribute{
+				MarkdownDescription: "OIDC scopes to request, separated by spaces",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("openid email profile"),
+			},
+			"auto_create_users": schema.BoolAttribute{
+				MarkdownDescription: "Create users signing in with this provider for the first time",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+		},
+	}
+}
+
+func (r *OIDCProviderResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fz.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
+
+func (r *OIDCProviderResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *OIDCProviderResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id := data.Id.ValueString()
+
+	configuration, err := modifyIdentityProviders(r.client, oidcProvidersField, func(entries []json.RawMessage) ([]json.RawMessage, error) {
+		if exists, _ := findIdentityProvider(entries, id, &firezoneOIDCProvider{}); exists {
+			return nil, errIdentityProviderExists(id)
+		}
+		return putIdentityProvider(entries, id, data.toOIDCProvider())
+	})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create OIDC provider, got error: %s", err))
+		return
+	}
+
+	provider, err := findOIDCProvider(configuration, id)
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read created OIDC provider, got error: %s", err))
+		return
+	}
+
+	if provider == nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("OIDC provider %s is missing after it was created", id))
+		return
+	}
+
+	data.fromOIDCProvider(provider)
+
+	// Write logs using the tflog package
+	// Documentation: https://terraform.io/plugin/log
+	tflog.Trace(ctx, "created a resource")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OIDCProviderResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *OIDCProviderResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	configuration, err := getConfiguration(r.client)
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read OIDC provider, got error: %s", err))
+		return
+	}
+
+	provider, err := findOIDCProvider(configuration, data.Id.ValueString())
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read OIDC provider, got error: %s", err))
+		return
+	}
+
+	if provider == nil {
+		// The provider was deleted outside of Terraform, e.g. in the admin UI.
+		tflog.Warn(ctx, "OIDC provider not found, removing from state", map[string]interface{}{"id": data.Id.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	data.fromOIDCProvider(provider)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OIDCProviderResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *OIDCProviderResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id := data.Id.ValueString()
+
+	configuration, err := modifyIdentityProviders(r.client, oidcProvidersField, func(entries []json.RawMessage) ([]json.RawMessage, error) {
+		return putIdentityProvider(entries, id, data.toOIDCProvider())
+	})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update OIDC provider, got error: %s", err))
+		return
+	}
+
+	provider, err := findOIDCProvider(configuration, id)
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read updated OIDC provider, got error: %s", err))
+		return
+	}
+
+	if provider == nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("OIDC provider %s is missing after it was updated", id))
+		return
+	}
+
+	data.fromOIDCProvider(provider)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OIDCProviderResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data *OIDCProviderResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, err := modifyIdentityProviders(r.client, oidcProvidersField, func(entries []json.RawMessage) ([]json.RawMessage, error) {
+		return removeIdentityProvider(entries, data.Id.ValueString()), nil
+	})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete OIDC provider, got error: %s", err))
+		return
+	}
+}
+
+func (r *OIDCProviderResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// findOIDCProvider returns the OIDC provider with id from configuration,
+// or nil if there is none.
+func findOIDCProvider(configuration *firezoneConfiguration, id string) (*firezoneOIDCProvider, error) {
+	var provider firezoneOIDCProvider
+
+	exists, err := findIdentityProvider(configuration.OpenIDConnectProviders, id, &provider)
+
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	return &provider, nil
+}
+
+// toOIDCProvider converts the model into a Firezone OIDC provider entry.
+func (m *OIDCProviderResourceModel) toOIDCProvider() firezoneOIDCProvider {
+	return firezoneOIDCProvider{
+		ID:                   m.Id.ValueString(),
+		Label:                m.Label.ValueString(),
+		ClientID:             m.ClientId.ValueString(),
+		ClientSecret:         m.ClientSecret.ValueString(),
+		DiscoveryDocumentURI: m.DiscoveryDocumentURI.ValueString(),
+		RedirectURI:          m.RedirectURI.ValueStringPointer(),
+		ResponseType:         m.ResponseType.ValueString(),
+		Scope:                m.Scope.ValueString(),
+		AutoCreateUsers:      m.AutoCreateUsers.ValueBool(),
+	}
+}
+
+// fromOIDCProvider copies a Firezone OIDC provider entry into the model.
+func (m *OIDCProviderResourceModel) fromOIDCProvider(provider *firezoneOIDCProvider) {
+	m.Id = types.StringValue(provider.ID)
+	m.Label = types.StringValue(provider.Label)
+	m.ClientId = types.StringValue(provider.ClientID)
+	m.DiscoveryDocumentURI = types.StringValue(provider.DiscoveryDocumentURI)
+	m.RedirectURI = types.StringPointerValue(provider.RedirectURI)
+	m.ResponseType = types.StringValue(provider.ResponseType)
+	m.Scope = types.StringValue(provider.Scope)
+	m.AutoCreateUsers = types.BoolValue(provider.AutoCreateUsers)
+
+	// Keep the known secret if the API does not return it.
+	if provider.ClientSecret != "" {
+		m.ClientSecret = types.StringValue(provider.ClientSecret)
+	}
+}
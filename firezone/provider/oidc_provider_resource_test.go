@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccOIDCProviderResource(t *testing.T) {
+	t.Cleanup(testAccFirezone.resetConfiguration)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckOIDCProviderResourceDestroy,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				PreConfig: func() {
+					testAccFirezone.updateConfig(func(c *fakeConfiguration) {
+						c.OpenIDConnectProviders = append(c.OpenIDConnectProviders, json.RawMessage(
+							`{"id":"google","label":"Google","client_id":"google-id","client_secret":"google-secret",`+
+								`"discovery_document_uri":"https://accounts.google.com/.well-known/openid-configuration","extra":"kept"}`,
+						))
+					})
+				},
+				Config: providerConfig + testAccOIDCProviderResourceConfig("Okta", ""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "id", "okta"),
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "label", "Okta"),
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "client_id", "okta-client"),
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "client_secret", "okta-secret"),
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "scope", "openid email profile"),
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "response_type", "code"),
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "auto_create_users", "false"),
+					resource.TestCheckNoResourceAttr("firezone_oidc_provider.test", "redirect_uri"),
+					testAccCheckOIDCProviderKept,
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      "firezone_oidc_provider.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			// Update and Read testing
+			{
+				Config: providerConfig + testAccOIDCProviderResourceConfig("Okta SSO", `
+  scope             = "openid email"
+  auto_create_users = true
+  redirect_uri      = "https://firezone.example.com/auth/oidc/okta/callback/"
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "label", "Okta SSO"),
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "scope", "openid email"),
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "auto_create_users", "true"),
+					resource.TestCheckResourceAttr("firezone_oidc_provider.test", "redirect_uri", "https://firezone.example.com/auth/oidc/okta/callback/"),
+					testAccCheckOIDCProviderKept,
+				),
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
+
+func TestAccOIDCProviderResource_exists(t *testing.T) {
+	t.Cleanup(testAccFirezone.resetConfiguration)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() {
+					testAccFirezone.updateConfig(func(c *fakeConfiguration) {
+						c.OpenIDConnectProviders = append(c.OpenIDConnectProviders, json.RawMessage(`{"id":"okta","label":"Okta"}`))
+					})
+				},
+				Config:      providerConfig + testAccOIDCProviderResourceConfig("Okta", ""),
+				ExpectError: regexp.MustCompile(`already\s+exists`),
+			},
+		},
+	})
+}
+
+func TestAccOIDCProviderResource_invalidID(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "firezone_oidc_provider" "test" {
+  id                     = "okta sso"
+  label                  = "Okta"
+  client_id              = "okta-client"
+  client_secret          = "okta-secret"
+  discovery_document_uri = "https://example.okta.com/.well-known/openid-configuration"
+}
+`,
+				ExpectError: regexp.MustCompile(`must\s+only\s+contain\s+letters`),
+			},
+		},
+	})
+}
+
+func TestAccOIDCProviderResource_disappears(t *testing.T) {
+	t.Cleanup(testAccFirezone.resetConfiguration)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckOIDCProviderResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccOIDCProviderResourceConfig("Okta", ""),
+				Check: func(s *terraform.State) error {
+					testAccFirezone.updateConfig(func(c *fakeConfiguration) {
+						c.OpenIDConnectProviders = []json.RawMessage{}
+					})
+					return nil
+				},
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+// testAccCheckOIDCProviderKept checks that the provider which is not
+// managed by Terraform is left untouched.
+func testAccCheckOIDCProviderKept(s *terraform.State) error {
+	var provider map[string]interface{}
+
+	exists, err := findIdentityProvider(testAccFirezone.config().OpenIDConnectProviders, "google", &provider)
+	if err != nil {
+		return err
+	}
+	if !exists || provider["extra"] != "kept" || provider["client_secret"] != "google-secret" {
+		return fmt.Errorf("unmanaged OIDC provider was changed: %v", provider)
+	}
+	return nil
+}
+
+func testAccCheckOIDCProviderResourceDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "firezone_oidc_provider" {
+			continue
+		}
+		exists, err := findIdentityProvider(testAccFirezone.config().OpenIDConnectProviders, rs.Primary.ID, &firezoneOIDCProvider{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("OIDC provider %s still exists", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccOIDCProviderResourceConfig(label string, extra string) string {
+	return fmt.Sprintf(`
+resource "firezone_oidc_provider" "test" {
+  id                     = "okta"
+  label                  = %[1]q
+  client_id              = "okta-client"
+  client_secret          = "okta-secret"
+  discovery_document_uri = "https://example.okta.com/.well-known/openid-configuration"
+  %[2]s
+}
+`, label, extra)
+}
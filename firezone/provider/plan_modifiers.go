@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+)
+
+var _ planmodifier.String = caseInsensitivePlanModifier{}
+
+// caseInsensitivePlanModifier keeps the value from the state when the
+// configured value only differs from it in case, so such changes do not
+// show up as diffs.
+type caseInsensitivePlanModifier struct{}
+
+func (m caseInsensitivePlanModifier) Description(ctx context.Context) string {
+	return "Ignores changes in case."
+}
+
+func (m caseInsensitivePlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m caseInsensitivePlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if !isKnown(req.StateValue) || !isKnown(req.PlanValue) {
+		return
+	}
+
+	if strings.EqualFold(req.StateValue.ValueString(), req.PlanValue.ValueString()) {
+		resp.PlanValue = req.StateValue
+	}
+}
+
+// caseInsensitive returns a plan modifier which ignores changes in case.
+func caseInsensitive() planmodifier.String {
+	return caseInsensitivePlanModifier{}
+}
+
+var _ planmodifier.String = portRangePlanModifier{}
+
+// portRangePlanModifier keeps the value from the state when the configured
+// port range is the same range written differently, e.g. 80-443 instead of
+// Firezone's 80 - 443, so such changes do not show up as diffs.
+type portRangePlanModifier struct{}
+
+func (m portRangePlanModifier) Description(ctx context.Context) string {
+	return "Ignores differences in how a port range is written."
+}
+
+func (m portRangePlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m portRangePlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if !isKnown(req.StateValue) || !isKnown(req.PlanValue) {
+		return
+	}
+
+	if samePortRange(req.StateValue.ValueString(), req.PlanValue.ValueString()) {
+		resp.PlanValue = req.StateValue
+	}
+}
+
+// equivalentPortRange returns a plan modifier which ignores differences
+// in how a port range is written.
+func equivalentPortRange() planmodifier.String {
+	return portRangePlanModifier{}
+}
@@ -2,13 +2,19 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	fz "github.com/jindrichskupa/firezone-client-go/client"
@@ -27,8 +33,19 @@ type FirezoneProvider struct {
 
 // FirezoneProviderModel describes the provider data model.
 type FirezoneProviderModel struct {
-	Endpoint types.String `tfsdk:"endpoint"`
-	ApiKey   types.String `tfsdk:"api_key"`
+	Endpoint     types.String `tfsdk:"endpoint"`
+	ApiKey       types.String `tfsdk:"api_key"`
+	MaxRetries   types.Int64  `tfsdk:"max_retries"`
+	RetryWaitMin types.String `tfsdk:"retry_wait_min"`
+	RetryWaitMax types.String `tfsdk:"retry_wait_max"`
+
+	CACertPEM          types.String `tfsdk:"ca_cert_pem"`
+	CACertFile         types.String `tfsdk:"ca_cert_file"`
+	ClientCert         types.String `tfsdk:"client_cert"`
+	ClientKey          types.String `tfsdk:"client_key"`
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+
+	SkipCredentialsValidation types.Bool `tfsdk:"skip_credentials_validation"`
 }
 
 func (p *FirezoneProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -40,14 +57,68 @@ func (p *FirezoneProvider) Schema(ctx context.Context, req provider.SchemaReques
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
 			"endpoint": schema.StringAttribute{
-				MarkdownDescription: "Firezone API endpoint",
-				Optional:            true,
+				MarkdownDescription: "Firezone API endpoint, e.g. `https://firezone.example.com`. " +
+					"The scheme defaults to `https`, a trailing `/v0` is removed. Alternative: FIREZONE_ENDPOINT environment variable",
+				Optional: true,
 			},
 			"api_key": schema.StringAttribute{
-				MarkdownDescription: "Firezone API key",
+				MarkdownDescription: "Firezone API key. Alternative: FIREZONE_API_KEY environment variable",
 				Optional:            true,
 				Sensitive:           true,
 			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum number of retries of a failed API request, defaults to %d. "+
+					"Rate limited requests are always retried, network errors and 5xx responses only for idempotent requests.", defaultMaxRetries),
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"retry_wait_min": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Minimum time to wait before retrying a request as a duration, e.g. `500ms`, defaults to `%s`. "+
+					"The wait doubles with every retry.", defaultRetryWaitMin),
+				Optional: true,
+			},
+			"retry_wait_max": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Maximum time to wait before retrying a request as a duration, defaults to `%s`. "+
+					"Also caps waits requested by a `Retry-After` header.", defaultRetryWaitMax),
+				Optional: true,
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded CA certificates to trust in addition to the system ones. " +
+					"Alternative: FIREZONE_CA_CERT_PEM environment variable",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("ca_cert_file")),
+				},
+			},
+			"ca_cert_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a file with PEM encoded CA certificates to trust in addition to the system ones. " +
+					"Alternative: FIREZONE_CA_CERT_FILE environment variable",
+				Optional: true,
+			},
+			"client_cert": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded client certificate for mutual TLS. " +
+					"Alternative: FIREZONE_CLIENT_CERT environment variable",
+				Optional: true,
+			},
+			"client_key": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded private key of `client_cert`. " +
+					"Alternative: FIREZONE_CLIENT_KEY environment variable",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				MarkdownDescription: "Skip verification of the API server certificate. Only use this for testing. " +
+					"Alternative: FIREZONE_INSECURE_SKIP_VERIFY environment variable",
+				Optional: true,
+			},
+			"skip_credentials_validation": schema.BoolAttribute{
+				MarkdownDescription: "Skip checking the endpoint and API key with a request to the Firezone API " +
+					"when the provider is configured, e.g. for plan-only runs without access to the API. " +
+					"Alternative: FIREZONE_SKIP_CREDENTIALS_VALIDATION environment variable",
+				Optional: true,
+			},
 		},
 	}
 }
@@ -78,6 +149,14 @@ func (p *FirezoneProvider) Configure(ctx context.Context, req provider.Configure
 			"Missing firezone endpoint",
 			"Alternative: FIREZONE_ENDPOINT environment variable",
 		)
+	} else if normalized, err := normalizeEndpoint(endpoint); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("endpoint"),
+			"Invalid firezone endpoint",
+			fmt.Sprintf("Unable to parse endpoint %q, got error: %s", endpoint, err),
+		)
+	} else {
+		endpoint = normalized
 	}
 
 	if api_key == "" {
@@ -88,6 +167,68 @@ func (p *FirezoneProvider) Configure(ctx context.Context, req provider.Configure
 		)
 	}
 
+	maxRetries := defaultMaxRetries
+	retryWaitMin := defaultRetryWaitMin
+	retryWaitMax := defaultRetryWaitMax
+
+	if !data.MaxRetries.IsNull() {
+		maxRetries = int(data.MaxRetries.ValueInt64())
+	}
+
+	if !data.RetryWaitMin.IsNull() {
+		retryWaitMin = parseDuration(data.RetryWaitMin.ValueString(), path.Root("retry_wait_min"), &resp.Diagnostics)
+	}
+
+	if !data.RetryWaitMax.IsNull() {
+		retryWaitMax = parseDuration(data.RetryWaitMax.ValueString(), path.Root("retry_wait_max"), &resp.Diagnostics)
+	}
+
+	if retryWaitMin > retryWaitMax {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("retry_wait_min"),
+			"Invalid retry wait",
+			fmt.Sprintf("retry_wait_min (%s) must not be greater than retry_wait_max (%s)", retryWaitMin, retryWaitMax),
+		)
+	}
+
+	tlsOptions := tlsSettings{
+		CACertPEM:          os.Getenv("FIREZONE_CA_CERT_PEM"),
+		CACertFile:         os.Getenv("FIREZONE_CA_CERT_FILE"),
+		ClientCert:         os.Getenv("FIREZONE_CLIENT_CERT"),
+		ClientKey:          os.Getenv("FIREZONE_CLIENT_KEY"),
+		InsecureSkipVerify: envBool("FIREZONE_INSECURE_SKIP_VERIFY", &resp.Diagnostics),
+	}
+
+	if !data.CACertPEM.IsNull() {
+		tlsOptions.CACertPEM = data.CACertPEM.ValueString()
+		tlsOptions.CACertFile = ""
+	}
+
+	if !data.CACertFile.IsNull() {
+		tlsOptions.CACertFile = data.CACertFile.ValueString()
+		tlsOptions.CACertPEM = ""
+	}
+
+	if !data.ClientCert.IsNull() {
+		tlsOptions.ClientCert = data.ClientCert.ValueString()
+	}
+
+	if !data.ClientKey.IsNull() {
+		tlsOptions.ClientKey = data.ClientKey.ValueString()
+	}
+
+	if !data.InsecureSkipVerify.IsNull() {
+		tlsOptions.InsecureSkipVerify = data.InsecureSkipVerify.ValueBool()
+	}
+
+	tlsConfig := tlsOptions.config(&resp.Diagnostics)
+
+	skipCredentialsValidation := envBool("FIREZONE_SKIP_CREDENTIALS_VALIDATION", &resp.Diagnostics)
+
+	if !data.SkipCredentialsValidation.IsNull() {
+		skipCredentialsValidation = data.SkipCredentialsValidation.ValueBool()
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -100,21 +241,68 @@ func (p *FirezoneProvider) Configure(ctx context.Context, req provider.Configure
 		)
 		return
 	}
+
+	// The client timeout would cover all retries together, so the retrying
+	// transport applies it to each attempt instead.
+	client.HTTPClient.Transport = &retryTransport{
+		base:       newBaseTransport(tlsConfig),
+		maxRetries: maxRetries,
+		waitMin:    retryWaitMin,
+		waitMax:    retryWaitMax,
+		timeout:    client.HTTPClient.Timeout,
+	}
+	client.HTTPClient.Timeout = 0
+
+	if !skipCredentialsValidation {
+		checkConnection(client, &resp.Diagnostics)
+
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	resp.DataSourceData = client
 	resp.ResourceData = client
 }
 
+// parseDuration parses a duration attribute, adding an attribute error
+// for invalid values.
+func parseDuration(value string, attributePath path.Path, diags *diag.Diagnostics) time.Duration {
+	duration, err := time.ParseDuration(value)
+
+	if err != nil || duration < 0 {
+		diags.AddAttributeError(
+			attributePath,
+			"Invalid duration",
+			fmt.Sprintf("%q is not a valid duration, expected a value like \"500ms\" or \"2s\"", value),
+		)
+		return 0
+	}
+
+	return duration
+}
+
 func (p *FirezoneProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewUserResource,
 		NewRuleResource,
 		NewDeviceResource,
+		NewWireGuardKeypairResource,
+		NewConfigurationResource,
+		NewOIDCProviderResource,
+		NewSAMLProviderResource,
+		NewRuleSetResource,
 	}
 }
 
 func (p *FirezoneProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewUserDataSource,
+		NewDeviceConfigDataSource,
+		NewDevicesDataSource,
+		NewUsersDataSource,
+		NewRulesDataSource,
+		NewDeviceDataSource,
 	}
 }
 
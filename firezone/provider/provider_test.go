@@ -1,31 +1,290 @@
 package provider
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 )
 
-const (
+const testAccApiKey = "my-api-key"
+
+var (
+	// testAccFirezone is the in-process fake Firezone API the acceptance
+	// tests run against. It is started by TestMain.
+	testAccFirezone *fakeFirezone
+
+	// testAccFirezoneURL is the endpoint of testAccFirezone.
+	testAccFirezoneURL string
+
 	// providerConfig is a shared configuration to combine with the actual
-	// test configuration so the HashiCups client is properly configured.
-	// It is also possible to use the HASHICUPS_ environment variables instead,
-	// such as updating the Makefile and running the testing through that tool.
-	providerConfig = `
-provider "firezone" {
-  api_key = "my-api-key"
-  endpoint     = "http://localhost:8080"
-}
-`
+	// test configuration so the Firezone client points at testAccFirezone.
+	providerConfig string
 )
 
 var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
 	"firezone": providerserver.NewProtocol6WithError(New("test")()),
 }
 
+func TestMain(m *testing.M) {
+	testAccFirezone = newFakeFirezone(testAccApiKey)
+	server := httptest.NewServer(testAccFirezone)
+	testAccFirezoneURL = server.URL
+
+	providerConfig = fmt.Sprintf(`
+provider "firezone" {
+  api_key  = %[1]q
+  endpoint = %[2]q
+}
+`, testAccApiKey, testAccFirezoneURL)
+
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
+
 func testAccPreCheck(t *testing.T) {
 	// You can add code here to run prior to any test case execution, for example assertions
 	// about the appropriate environment variables being set are common to see in a pre-check
 	// function.
 }
+
+// testAccCheckDisappears deletes the object behind a resource directly in
+// testAccFirezone, as if it had been removed in the Firezone admin UI.
+func testAccCheckDisappears(name string, remove func(id string)) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+		remove(rs.Primary.ID)
+		return nil
+	}
+}
+
+// testAccImportStateIdFunc builds an import identifier by joining
+// attributes of the resource with slashes.
+func testAccImportStateIdFunc(name string, attributes ...string) resource.ImportStateIdFunc {
+	return func(s *terraform.State) (string, error) {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return "", fmt.Errorf("resource %s not found", name)
+		}
+		parts := make([]string, 0, len(attributes))
+		for _, attribute := range attributes {
+			parts = append(parts, rs.Primary.Attributes[attribute])
+		}
+		return strings.Join(parts, "/"), nil
+	}
+}
+
+// testAccCheckSameId records the id of the resource in id the first time
+// it is called and checks it is unchanged on later calls, to make sure the
+// resource was updated in place rather than replaced.
+func testAccCheckSameId(name string, id *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+		if *id == "" {
+			*id = rs.Primary.ID
+			return nil
+		}
+		if rs.Primary.ID != *id {
+			return fmt.Errorf("resource %s was replaced, id changed from %s to %s", name, *id, rs.Primary.ID)
+		}
+		return nil
+	}
+}
+
+func TestAccProvider_retries(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderRetriesConfig() + `
+resource "firezone_user" "test" {
+  email = "retries@example.com"
+  role  = "unprivileged"
+}
+`,
+			},
+			// A transient gateway error while refreshing is retried
+			{
+				PreConfig: func() {
+					testAccFirezone.respondOnce(http.MethodGet, "/v0/users/retries@example.com", http.StatusBadGateway)
+				},
+				Config: testAccProviderRetriesConfig() + `
+resource "firezone_user" "test" {
+  email = "retries@example.com"
+  role  = "unprivileged"
+}
+
+data "firezone_user" "test" {
+  email = firezone_user.test.email
+}
+`,
+				Check: resource.TestCheckResourceAttrPair("data.firezone_user.test", "id", "firezone_user.test", "id"),
+			},
+		},
+	})
+}
+
+func TestAccProvider_invalidRetryWait(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: strings.Replace(testAccProviderRetriesConfig(), `"10ms"`, `"soon"`, 1) + `
+data "firezone_user" "test" {
+  email = "nobody@example.com"
+}
+`,
+				ExpectError: regexp.MustCompile(`Invalid duration`),
+			},
+		},
+	})
+}
+
+func testAccProviderRetriesConfig() string {
+	return fmt.Sprintf(`
+provider "firezone" {
+  api_key        = %[1]q
+  endpoint       = %[2]q
+  max_retries    = 2
+  retry_wait_min = "10ms"
+  retry_wait_max = "50ms"
+}
+`, testAccApiKey, testAccFirezoneURL)
+}
+
+// testAccProviderNoRetriesConfig configures the provider to give up after
+// the first failed request.
+func testAccProviderNoRetriesConfig() string {
+	return fmt.Sprintf(`
+provider "firezone" {
+  api_key     = %[1]q
+  endpoint    = %[2]q
+  max_retries = 0
+}
+`, testAccApiKey, testAccFirezoneURL)
+}
+
+func TestAccProvider_endpointNormalization(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderCredentialsConfig(testAccFirezoneURL+"/v0/", testAccApiKey, "") + `
+resource "firezone_user" "test" {
+  email = "normalized@example.com"
+  role  = "unprivileged"
+}
+`,
+				Check: resource.TestCheckResourceAttrSet("firezone_user.test", "id"),
+			},
+		},
+	})
+}
+
+func TestAccProvider_invalidEndpoint(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderCredentialsConfig("ftp://firezone.example.com", testAccApiKey, "") + testAccProviderCredentialsDataSourceConfig,
+				ExpectError: regexp.MustCompile(`Invalid firezone endpoint`),
+			},
+			{
+				Config:      testAccProviderCredentialsConfig(testAccFirezoneURL+"/firezone", testAccApiKey, "") + testAccProviderCredentialsDataSourceConfig,
+				ExpectError: regexp.MustCompile(`Invalid firezone endpoint`),
+			},
+		},
+	})
+}
+
+func TestAccProvider_unreachableEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderCredentialsConfig(server.URL, testAccApiKey, "") + testAccProviderCredentialsDataSourceConfig,
+				ExpectError: regexp.MustCompile(`Unable to reach firezone endpoint`),
+			},
+		},
+	})
+}
+
+func TestAccProvider_invalidApiKey(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderCredentialsConfig(testAccFirezoneURL, "revoked-api-key", "") + testAccProviderCredentialsDataSourceConfig,
+				ExpectError: regexp.MustCompile(`Invalid firezone api key`),
+			},
+			{
+				PreConfig: func() {
+					testAccFirezone.respondOnce(http.MethodGet, "/v0/configuration", http.StatusForbidden)
+				},
+				Config:      testAccProviderCredentialsConfig(testAccFirezoneURL, testAccApiKey, "") + testAccProviderCredentialsDataSourceConfig,
+				ExpectError: regexp.MustCompile(`Insufficient firezone api key permissions`),
+			},
+		},
+	})
+}
+
+func TestAccProvider_skipCredentialsValidation(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Planning a new resource does not need the API
+			{
+				Config: testAccProviderCredentialsConfig("firezone.invalid", "revoked-api-key", "skip_credentials_validation = true") + `
+resource "firezone_user" "test" {
+  email = "offline@example.com"
+  role  = "unprivileged"
+}
+`,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+const testAccProviderCredentialsDataSourceConfig = `
+data "firezone_user" "test" {
+  email = "nobody@example.com"
+}
+`
+
+func testAccProviderCredentialsConfig(endpoint string, apiKey string, settings string) string {
+	return fmt.Sprintf(`
+provider "firezone" {
+  api_key     = %[1]q
+  endpoint    = %[2]q
+  max_retries = 0
+  %[3]s
+}
+`, apiKey, endpoint, settings)
+}
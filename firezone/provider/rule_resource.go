@@ -3,7 +3,10 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/netip"
 	"regexp"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/path"
@@ -21,6 +24,7 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &RuleResource{}
 var _ resource.ResourceWithImportState = &RuleResource{}
+var _ resource.ResourceWithValidateConfig = &RuleResource{}
 
 func NewRuleResource() resource.Resource {
 	return &RuleResource{}
@@ -33,12 +37,12 @@ type RuleResource struct {
 
 // RuleResourceModel describes the resource data model.
 type RuleResourceModel struct {
-	Id          types.String `tfsdk:"id"`
-	UserId      types.String `tfsdk:"user_id"`
-	Action      types.String `tfsdk:"action"`
-	Destination types.String `tfsdk:"destination"`
-	PortRange   types.String `tfsdk:"port_range"`
-	PortType    types.String `tfsdk:"port_type"`
+	Id          types.String     `tfsdk:"id"`
+	UserId      types.String     `tfsdk:"user_id"`
+	Action      types.String     `tfsdk:"action"`
+	Destination DestinationValue `tfsdk:"destination"`
+	PortRange   types.String     `tfsdk:"port_range"`
+	PortType    types.String     `tfsdk:"port_type"`
 }
 
 func (r *RuleResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -52,12 +56,8 @@ func (r *RuleResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 
 		Attributes: map[string]schema.Attribute{
 			"port_type": schema.StringAttribute{
-				MarkdownDescription: "Rule port type",
-				Required:            true,
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.UseStateForUnknown(),
-					stringplanmodifier.RequiresReplaceIfConfigured(),
-				},
+				MarkdownDescription: "Rule port type, either `tcp` or `udp`. Must be set together with `port_range`, leave both unset to match all protocols and ports.",
+				Optional:            true,
 				Validators: []validator.String{
 					// These are example validators from terraform-plugin-framework-validators
 					stringvalidator.LengthBetween(1, 256),
@@ -65,39 +65,31 @@ func (r *RuleResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 						regexp.MustCompile(`^(tcp|udp)$`),
 						"must be either 'tcp' or 'udp'",
 					),
+					stringvalidator.AlsoRequires(path.MatchRoot("port_range")),
 				},
 			},
 			"port_range": schema.StringAttribute{
-				MarkdownDescription: "Rule port range",
-				Required:            true,
+				MarkdownDescription: "Rule port range, a single port like `443` or a range like `80-443` or `80 - 443`. Ports are between 1 and 65535. Must be set together with `port_type`, leave both unset to match all protocols and ports.",
+				Optional:            true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.UseStateForUnknown(),
-					stringplanmodifier.RequiresReplaceIfConfigured(),
+					equivalentPortRange(),
 				},
 				Validators: []validator.String{
-					// These are example validators from terraform-plugin-framework-validators
-					stringvalidator.LengthBetween(1, 256),
-					stringvalidator.RegexMatches(
-						regexp.MustCompile(`^([1-9]?[0-9]*|[1-9]?[0-9]* - [1-9]?[0-9]*)$`),
-						"must contain single port or port range in format 'port' or 'port - port' (with spaces)",
-					),
+					isPortRange(),
+					stringvalidator.AlsoRequires(path.MatchRoot("port_type")),
 				},
 			},
 			"destination": schema.StringAttribute{
-				MarkdownDescription: "Rule destination",
+				MarkdownDescription: "Rule destination, an IPv4 or IPv6 network in CIDR notation or a single address. Networks are compared after clearing host bits, and single addresses are the same as `/32` or `/128` networks.",
 				Required:            true,
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.UseStateForUnknown(),
-					stringplanmodifier.RequiresReplaceIfConfigured(),
+				CustomType:          DestinationType{},
+				Validators: []validator.String{
+					isRuleDestination(),
 				},
 			},
 			"action": schema.StringAttribute{
 				MarkdownDescription: "Rule action",
 				Required:            true,
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.UseStateForUnknown(),
-					stringplanmodifier.RequiresReplaceIfConfigured(),
-				},
 				Validators: []validator.String{
 					// These are example validators from terraform-plugin-framework-validators
 					stringvalidator.LengthBetween(1, 256),
@@ -108,20 +100,14 @@ func (r *RuleResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 				},
 			},
 			"user_id": schema.StringAttribute{
-				MarkdownDescription: "Rule user id",
-				Computed:            true,
+				MarkdownDescription: "Rule user id, the rule applies to all users when unset",
 				Optional:            true,
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.UseStateForUnknown(),
-					stringplanmodifier.RequiresReplaceIfConfigured(),
-				},
 			},
 			"id": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Rule identifier",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
-					stringplanmodifier.RequiresReplaceIfConfigured(),
 				},
 			},
 		},
@@ -158,7 +144,7 @@ func (r *RuleResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	rule, err := r.client.CreateRule(fz.Rule{
+	rule, err := createRule(r.client, fz.Rule{
 		UserId:      data.UserId.ValueString(),
 		Action:      data.Action.ValueString(),
 		Destination: data.Destination.ValueString(),
@@ -171,12 +157,7 @@ func (r *RuleResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	data.Id = types.StringValue(rule.ID)
-	data.UserId = types.StringValue(rule.UserId)
-	data.Action = types.StringValue(rule.Action)
-	data.Destination = types.StringValue(rule.Destination)
-	data.PortRange = types.StringValue(rule.PortRange)
-	data.PortType = types.StringValue(rule.PortType)
+	data.fromRule(rule)
 
 	// Write logs using the tflog package
 	// Documentation: https://terraform.io/plugin/log
@@ -198,17 +179,19 @@ func (r *RuleResource) Read(ctx context.Context, req resource.ReadRequest, resp
 
 	rule, err := r.client.GetRule(data.Id.ValueString())
 
+	if isNotFound(err) {
+		// The rule was deleted outside of Terraform, e.g. in the admin UI.
+		tflog.Warn(ctx, "rule not found, removing from state", map[string]interface{}{"id": data.Id.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read rule, got error: %s", err))
 		return
 	}
 
-	data.Id = types.StringValue(rule.ID)
-	data.UserId = types.StringValue(rule.UserId)
-	data.Action = types.StringValue(rule.Action)
-	data.Destination = types.StringValue(rule.Destination)
-	data.PortRange = types.StringValue(rule.PortRange)
-	data.PortType = types.StringValue(rule.PortType)
+	data.fromRule(rule)
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
@@ -224,7 +207,8 @@ func (r *RuleResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
-	rule, err := r.client.UpdateRule(data.Id.ValueString(), fz.Rule{
+	// Every field is sent so Firezone changes the rule in place.
+	rule, err := updateRule(r.client, data.Id.ValueString(), fz.Rule{
 		UserId:      data.UserId.ValueString(),
 		Action:      data.Action.ValueString(),
 		Destination: data.Destination.ValueString(),
@@ -237,12 +221,7 @@ func (r *RuleResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
-	data.Id = types.StringValue(rule.ID)
-	data.UserId = types.StringValue(rule.UserId)
-	data.Action = types.StringValue(rule.Action)
-	data.Destination = types.StringValue(rule.Destination)
-	data.PortRange = types.StringValue(rule.PortRange)
-	data.PortType = types.StringValue(rule.PortType)
+	data.fromRule(rule)
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
@@ -260,15 +239,191 @@ func (r *RuleResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 
 	err := r.client.DeleteRule(data.Id.ValueString())
 
-	// If applicable, this is a great opportunity to initialize any necessary
-	// provider client data and make a call using it.
-	// httpResp, err := r.client.Do(httpReq)
+	// A rule which is already gone is as good as deleted.
+	if isNotFound(err) {
+		return
+	}
+
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete rule, got error: %s", err))
 		return
 	}
 }
 
+func (r *RuleResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data RuleResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !isKnown(data.Action) || data.Action.ValueString() != "accept" || !isKnown(data.Destination) {
+		return
+	}
+
+	network, ok := parseRuleDestination(data.Destination.ValueString())
+
+	if ok && isBroadNetwork(network) {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("destination"),
+			"Broad rule destination",
+			fmt.Sprintf("The rule accepts traffic to %s, which covers a large part of the address space. "+
+				"Prefer more specific networks unless this is intended.", network),
+		)
+	}
+}
+
 func (r *RuleResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	// Rules are imported by id or by
+	// <user>/<action>/<destination>/<port_type>/<port_range>, where the user
+	// is an email or id and empty for global rules and the port type and
+	// range are empty for rules matching all ports. The destination may
+	// contain a slash itself, so the parts are taken from both ends.
+	if isUUID(req.ID) {
+		resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+		return
+	}
+
+	parts := strings.Split(req.ID, "/")
+
+	if len(parts) < 5 {
+		resp.Diagnostics.AddError(
+			"Unexpected Import Identifier",
+			fmt.Sprintf("Expected a rule id or <user>/<action>/<destination>/<port_type>/<port_range>. Got: %q", req.ID),
+		)
+		return
+	}
+
+	n := len(parts)
+	user, action, destination, portType, portRange := parts[0], parts[1], strings.Join(parts[2:n-2], "/"), parts[n-2], parts[n-1]
+
+	userId := ""
+
+	if user != "" {
+		var ok bool
+		userId, ok = importUserID(r.client, &resp.Diagnostics, user)
+
+		if !ok {
+			return
+		}
+	}
+
+	rules, err := r.client.GetAllRules()
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read rules, got error: %s", err))
+		return
+	}
+
+	var ids []string
+
+	for _, rule := range *rules {
+		if rule.UserId == userId &&
+			rule.Action == action &&
+			sameRuleDestination(rule.Destination, destination) &&
+			rule.PortType == portType &&
+			samePortRange(rule.PortRange, portRange) {
+			ids = append(ids, rule.ID)
+		}
+	}
+
+	id, ok := importMatch(&resp.Diagnostics, "rule", req.ID, ids)
+
+	if !ok {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+}
+
+// fromRule copies a Firezone rule into the model.
+func (m *RuleResourceModel) fromRule(rule *fz.Rule) {
+	m.Id = types.StringValue(rule.ID)
+	m.UserId = optionalString(rule.UserId)
+	m.Action = types.StringValue(rule.Action)
+	m.Destination = DestinationStringValue(rule.Destination)
+
+	m.PortType = optionalString(rule.PortType)
+
+	// Firezone returns port ranges in its own form, keep the configured one
+	// if it is the same range. Rules without ports match all ports.
+	if !samePortRange(m.PortRange.ValueString(), rule.PortRange) {
+		m.PortRange = optionalString(rule.PortRange)
+	}
+}
+
+// createRule creates a rule. The Firezone client sends empty strings for
+// unset ports, which Firezone rejects for rules matching all ports.
+func createRule(client *fz.Client, input fz.Rule) (*fz.Rule, error) {
+	var rule fz.Rule
+
+	if err := apiRequest(client, http.MethodPost, "/v0/rules", ruleBody(input), &rule); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// updateRule replaces all fields of a rule. The Firezone client leaves
+// empty fields out of updates, so a rule could not be made global.
+func updateRule(client *fz.Client, id string, input fz.Rule) (*fz.Rule, error) {
+	var rule fz.Rule
+
+	if err := apiRequest(client, http.MethodPatch, "/v0/rules/"+id, ruleBody(input), &rule); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// ruleBody returns the request body for a rule, sending empty fields as
+// JSON null.
+func ruleBody(input fz.Rule) map[string]interface{} {
+	return map[string]interface{}{
+		"rule": map[string]interface{}{
+			"user_id":     nullIfEmpty(input.UserId),
+			"action":      input.Action,
+			"destination": input.Destination,
+			"port_range":  nullIfEmpty(input.PortRange),
+			"port_type":   nullIfEmpty(input.PortType),
+		},
+	}
+}
+
+// nullIfEmpty maps the empty string to JSON null.
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// isBroadNetwork reports whether a network is unusually broad for a rule,
+// i.e. larger than a /8 for IPv4 or a /16 for IPv6.
+func isBroadNetwork(network netip.Prefix) bool {
+	if network.Addr().Is4() {
+		return network.Bits() < 8
+	}
+	return network.Bits() < 16
+}
+
+// sameRuleDestination reports whether two rule destinations are the same
+// network, e.g. 10.0.0.1 and 10.0.0.1/32.
+func sameRuleDestination(a string, b string) bool {
+	networkA, okA := parseRuleDestination(a)
+	networkB, okB := parseRuleDestination(b)
+
+	if okA && okB {
+		return networkA == networkB
+	}
+
+	return a == b
+}
+
+// samePortRange reports whether two port ranges are the same, e.g. 80-443
+// and 80 - 443 or 443 and 443-443.
+func samePortRange(a string, b string) bool {
+	return canonicalPortRange(a) == canonicalPortRange(b)
 }
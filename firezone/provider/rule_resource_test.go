@@ -2,23 +2,32 @@ package provider
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 )
 
 func TestAccRuleResource(t *testing.T) {
+	var id string
+
 	resource.Test(t, resource.TestCase{
 		PreCheck:                 func() { testAccPreCheck(t) },
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckRuleResourceDestroy,
 		Steps: []resource.TestStep{
 			// Create and Read testing
 			{
-				Config: providerConfig + testAccRuleResourceConfig(),
+				Config: providerConfig + testAccRuleResourceConfig("drop", "10.0.0.0/8", "80 - 443"),
 				Check: resource.ComposeAggregateTestCheckFunc(
-					resource.TestCheckResourceAttr("firezone_rule.test", "email", "one@example.com"),
-					resource.TestCheckResourceAttr("firezone_rule.test", "role", "unprivileged"),
-					resource.TestCheckResourceAttr("firezone_rule.test", "id", "example-id"),
+					resource.TestCheckResourceAttrPair("firezone_rule.test", "user_id", "firezone_user.test", "id"),
+					resource.TestCheckResourceAttr("firezone_rule.test", "action", "drop"),
+					resource.TestCheckResourceAttr("firezone_rule.test", "destination", "10.0.0.0/8"),
+					resource.TestCheckResourceAttr("firezone_rule.test", "port_range", "80 - 443"),
+					resource.TestCheckResourceAttr("firezone_rule.test", "port_type", "tcp"),
+					resource.TestCheckResourceAttrSet("firezone_rule.test", "id"),
+					testAccCheckSameId("firezone_rule.test", &id),
 				),
 			},
 			// ImportState testing
@@ -26,18 +35,39 @@ func TestAccRuleResource(t *testing.T) {
 				ResourceName:      "firezone_rule.test",
 				ImportState:       true,
 				ImportStateVerify: true,
-				// This is not normally necessary, but is here because this
-				// example code does not have an actual upstream service.
-				// Once the Read method is able to refresh information from
-				// the upstream service, this can be removed.
-				ImportStateVerifyIgnore: []string{"email", "defaulted"},
 			},
-			// Update and Read testing
 			{
-				Config: testAccRuleResourceConfig(),
+				ResourceName:      "firezone_rule.test",
+				ImportState:       true,
+				ImportStateId:     "rule@example.com/drop/10.0.0.0/8/tcp/80-443",
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "firezone_rule.test",
+				ImportState:       true,
+				ImportStateIdFunc: testAccImportStateIdFunc("firezone_rule.test", "user_id", "action", "destination", "port_type", "port_range"),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:  "firezone_rule.test",
+				ImportState:   true,
+				ImportStateId: "rule@example.com/accept/10.0.0.0/8/tcp/80-443",
+				ExpectError:   regexp.MustCompile(`No\s+rule\s+matches\s+the\s+import\s+identifier`),
+			},
+			{
+				ResourceName:  "firezone_rule.test",
+				ImportState:   true,
+				ImportStateId: "rule@example.com/drop/10.0.0.0/8",
+				ExpectError:   regexp.MustCompile(`Expected\s+a\s+rule\s+id\s+or`),
+			},
+			// Update and Read testing, rules are changed in place.
+			{
+				Config: providerConfig + testAccRuleResourceConfig("accept", "192.168.0.0/16", "443"),
 				Check: resource.ComposeAggregateTestCheckFunc(
-					resource.TestCheckResourceAttr("firezone_rule.test", "email", "two@example.com"),
-					resource.TestCheckResourceAttr("firezone_rule.test", "role", "unprivileged"),
+					resource.TestCheckResourceAttr("firezone_rule.test", "action", "accept"),
+					resource.TestCheckResourceAttr("firezone_rule.test", "destination", "192.168.0.0/16"),
+					resource.TestCheckResourceAttr("firezone_rule.test", "port_range", "443"),
+					testAccCheckSameId("firezone_rule.test", &id),
 				),
 			},
 			// Delete testing automatically occurs in TestCase
@@ -45,14 +75,357 @@ func TestAccRuleResource(t *testing.T) {
 	})
 }
 
-func testAccRuleResourceConfig() string {
+func TestAccRuleResource_global(t *testing.T) {
+	var id string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckRuleResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "firezone_rule" "test" {
+  action      = "drop"
+  destination = "172.16.0.0/12"
+  port_range  = "22"
+  port_type   = "tcp"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("firezone_rule.test", "user_id"),
+					resource.TestCheckResourceAttr("firezone_rule.test", "destination", "172.16.0.0/12"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+			// Global rules have no user.
+			{
+				ResourceName:      "firezone_rule.test",
+				ImportState:       true,
+				ImportStateId:     "/drop/172.16.0.0/12/tcp/22",
+				ImportStateVerify: true,
+			},
+			// Scoping the rule to a user changes it in place.
+			{
+				Config: providerConfig + `
+resource "firezone_user" "test" {
+  email = "global@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_rule" "test" {
+  user_id     = firezone_user.test.id
+  action      = "drop"
+  destination = "172.16.0.0/12"
+  port_range  = "22"
+  port_type   = "udp"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("firezone_rule.test", "user_id", "firezone_user.test", "id"),
+					resource.TestCheckResourceAttr("firezone_rule.test", "port_type", "udp"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+			// Removing the user makes the rule global again in place.
+			{
+				Config: providerConfig + `
+resource "firezone_user" "test" {
+  email = "global@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_rule" "test" {
+  action      = "drop"
+  destination = "172.16.0.0/12"
+  port_range  = "22"
+  port_type   = "udp"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("firezone_rule.test", "user_id"),
+					testAccCheckRuleGlobal("firezone_rule.test"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+		},
+	})
+}
+
+func TestAccRuleResource_invalidDestination(t *testing.T) {
+	for _, destination := range []string{"not-a-network", "10.0.0.0/33", "2001:db8::/129", "10.0.0.256"} {
+		resource.Test(t, resource.TestCase{
+			PreCheck:                 func() { testAccPreCheck(t) },
+			ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+			Steps: []resource.TestStep{
+				{
+					Config:      providerConfig + testAccRuleResourceConfig("drop", destination, "22"),
+					ExpectError: regexp.MustCompile(`must be an IPv4 or IPv6 network`),
+				},
+			},
+		})
+	}
+}
+
+func TestAccRuleResource_destination(t *testing.T) {
+	var id string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckRuleResourceDestroy,
+		Steps: []resource.TestStep{
+			// The configured form is kept although Firezone returns 10.0.0.1/32.
+			{
+				Config: providerConfig + testAccRuleResourceConfig("drop", "10.0.0.1", "22"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule.test", "destination", "10.0.0.1"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+			{
+				Config:   providerConfig + testAccRuleResourceConfig("drop", "10.0.0.1/32", "22"),
+				PlanOnly: true,
+			},
+			// Host bits are cleared by Firezone.
+			{
+				Config: providerConfig + testAccRuleResourceConfig("drop", "10.1.2.3/8", "22"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule.test", "destination", "10.1.2.3/8"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+			{
+				Config:   providerConfig + testAccRuleResourceConfig("drop", "10.0.0.0/8", "22"),
+				PlanOnly: true,
+			},
+			{
+				Config: providerConfig + testAccRuleResourceConfig("drop", "2001:db8::1", "22"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule.test", "destination", "2001:db8::1"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+			{
+				Config:   providerConfig + testAccRuleResourceConfig("drop", "2001:db8::1/128", "22"),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccRuleResource_portRange(t *testing.T) {
+	var id string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckRuleResourceDestroy,
+		Steps: []resource.TestStep{
+			// The configured form is kept although Firezone returns 80 - 443.
+			{
+				Config: providerConfig + testAccRuleResourceConfig("drop", "10.0.0.0/8", "80-443"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule.test", "port_range", "80-443"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+			// Writing the same range differently is not a change.
+			{
+				Config:   providerConfig + testAccRuleResourceConfig("drop", "10.0.0.0/8", "80 - 443"),
+				PlanOnly: true,
+			},
+			{
+				Config:   providerConfig + testAccRuleResourceConfig("drop", "10.0.0.0/8", " 80 -443"),
+				PlanOnly: true,
+			},
+			// A different range is.
+			{
+				Config: providerConfig + testAccRuleResourceConfig("drop", "10.0.0.0/8", "443-443"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule.test", "port_range", "443-443"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+			{
+				Config:   providerConfig + testAccRuleResourceConfig("drop", "10.0.0.0/8", "443"),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccRuleResource_invalidPortRange(t *testing.T) {
+	for _, portRange := range []string{"", "0", "65536", "900 - 80", "80,443"} {
+		resource.Test(t, resource.TestCase{
+			PreCheck:                 func() { testAccPreCheck(t) },
+			ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+			Steps: []resource.TestStep{
+				{
+					Config:      providerConfig + testAccRuleResourceConfig("drop", "10.0.0.0/8", portRange),
+					ExpectError: regexp.MustCompile(`must be a port or a port range`),
+				},
+			},
+		})
+	}
+}
+
+func TestAccRuleResource_portTypeRequired(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "firezone_rule" "test" {
+  action      = "drop"
+  destination = "10.0.0.0/8"
+  port_range  = "22"
+}
+`,
+				ExpectError: regexp.MustCompile(`port_type`),
+			},
+		},
+	})
+}
+
+func TestAccRuleResource_allPorts(t *testing.T) {
+	var id string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckRuleResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "firezone_rule" "test" {
+  action      = "drop"
+  destination = "10.0.0.0/8"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("firezone_rule.test", "port_range"),
+					resource.TestCheckNoResourceAttr("firezone_rule.test", "port_type"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+			{
+				ResourceName:      "firezone_rule.test",
+				ImportState:       true,
+				ImportStateId:     "/drop/10.0.0.0/8//",
+				ImportStateVerify: true,
+			},
+			// Ports can be added and removed again in place.
+			{
+				Config: providerConfig + `
+resource "firezone_rule" "test" {
+  action      = "drop"
+  destination = "10.0.0.0/8"
+  port_range  = "22"
+  port_type   = "tcp"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule.test", "port_range", "22"),
+					resource.TestCheckResourceAttr("firezone_rule.test", "port_type", "tcp"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+			{
+				Config: providerConfig + `
+resource "firezone_rule" "test" {
+  action      = "drop"
+  destination = "10.0.0.0/8"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckNoResourceAttr("firezone_rule.test", "port_range"),
+					resource.TestCheckNoResourceAttr("firezone_rule.test", "port_type"),
+					testAccCheckSameId("firezone_rule.test", &id),
+				),
+			},
+		},
+	})
+}
+
+func TestAccRuleResource_portRangeRequired(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "firezone_rule" "test" {
+  action      = "drop"
+  destination = "10.0.0.0/8"
+  port_type   = "udp"
+}
+`,
+				ExpectError: regexp.MustCompile(`port_range`),
+			},
+		},
+	})
+}
+
+func TestAccRuleResource_disappears(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckRuleResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:             providerConfig + testAccRuleResourceConfig("drop", "10.0.0.0/8", "80 - 443"),
+				Check:              testAccCheckDisappears("firezone_rule.test", testAccFirezone.deleteRule),
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func testAccCheckRuleResourceDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "firezone_rule" {
+			continue
+		}
+		if testAccFirezone.rule(rs.Primary.ID) != nil {
+			return fmt.Errorf("rule %s still exists", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+// testAccCheckRuleGlobal checks the rule has no user in Firezone.
+func testAccCheckRuleGlobal(name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+		rule := testAccFirezone.rule(rs.Primary.ID)
+		if rule == nil {
+			return fmt.Errorf("rule %s not found", rs.Primary.ID)
+		}
+		if rule.UserID != nil {
+			return fmt.Errorf("rule %s belongs to user %s", rs.Primary.ID, *rule.UserID)
+		}
+		return nil
+	}
+}
+
+func testAccRuleResourceConfig(action string, destination string, portRange string) string {
 	return fmt.Sprintf(`
+resource "firezone_user" "test" {
+  email = "rule@example.com"
+  role  = "unprivileged"
+}
+
 resource "firezone_rule" "test" {
-	user_id = "example-id"
-	action = "allow"
-	destination = "0.0.0.0/0"
-	port_range = "0-65535"
-	port_type = "tcp"
+  user_id     = firezone_user.test.id
+  action      = %[1]q
+  destination = %[2]q
+  port_range  = %[3]q
+  port_type   = "tcp"
 }
-`)
+`, action, destination, portRange)
 }
@@ -0,0 +1,658 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &RuleSetResource{}
+var _ resource.ResourceWithModifyPlan = &RuleSetResource{}
+var _ resource.ResourceWithValidateConfig = &RuleSetResource{}
+
+func NewRuleSetResource() resource.Resource {
+	return &RuleSetResource{}
+}
+
+// RuleSetResource defines the resource implementation. A rule set manages
+// many Firezone rules of one user, or global rules, as one resource and
+// only creates and deletes the rules which changed.
+type RuleSetResource struct {
+	client *fz.Client
+}
+
+// RuleSetResourceModel describes the resource data model. The rules are a
+// list value rather than a slice, so configurations with unknown rules can
+// be validated and planned.
+type RuleSetResourceModel struct {
+	Id        types.String `tfsdk:"id"`
+	UserId    types.String `tfsdk:"user_id"`
+	Exclusive types.Bool   `tfsdk:"exclusive"`
+	Rules     types.List   `tfsdk:"rule"`
+}
+
+// RuleSetEntryModel describes a rule of a rule set.
+type RuleSetEntryModel struct {
+	Id          types.String     `tfsdk:"id"`
+	Action      types.String     `tfsdk:"action"`
+	Destination DestinationValue `tfsdk:"destination"`
+	PortRange   types.String     `tfsdk:"port_range"`
+	PortType    types.String     `tfsdk:"port_type"`
+}
+
+// ruleSetEntryType is the type of the rule set list elements.
+var ruleSetEntryType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"id":          types.StringType,
+		"action":      types.StringType,
+		"destination": DestinationType{},
+		"port_range":  types.StringType,
+		"port_type":   types.StringType,
+	},
+}
+
+func (r *RuleSetResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rule_set"
+}
+
+func (r *RuleSetResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Rule set resource. Manages the rules of a user, or global rules, as one unit. " +
+			"Changes only create and delete the rules which differ from the previous ones.",
+
+		Attributes: map[string]schema.Attribute{
+			"user_id": schema.StringAttribute{
+				MarkdownDescription: "Rule set user id, the rules apply to all users when unset. Changing it replaces the rule set.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"exclusive": schema.BoolAttribute{
+				MarkdownDescription: "Whether the rule set owns all rules of its user, or all global rules. " +
+					"Rules not in the rule set are then deleted.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Rule set identifier, the user id or `global`",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+
+		Blocks: map[string]schema.Block{
+			"rule": schema.ListNestedBlock{
+				MarkdownDescription: "Rules of the rule set",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"action": schema.StringAttribute{
+							MarkdownDescription: "Rule action",
+							Required:            true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(
+									regexp.MustCompile(`^(accept|drop)$`),
+									"must be either 'accept' or 'drop'",
+								),
+							},
+						},
+						"destination": schema.StringAttribute{
+							MarkdownDescription: "Rule destination, an IPv4 or IPv6 network in CIDR notation or a single address",
+							Required:            true,
+							CustomType:          DestinationType{},
+							Validators: []validator.String{
+								isRuleDestination(),
+							},
+						},
+						"port_type": schema.StringAttribute{
+							MarkdownDescription: "Rule port type, either `tcp` or `udp`. Must be set together with `port_range`, leave both unset to match all protocols and ports.",
+							Optional:            true,
+							Validators: []validator.String{
+								stringvalidator.RegexMatches(
+									regexp.MustCompile(`^(tcp|udp)$`),
+									"must be either 'tcp' or 'udp'",
+								),
+								stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("port_range")),
+							},
+						},
+						"port_range": schema.StringAttribute{
+							MarkdownDescription: "Rule port range, a single port like `443` or a range like `80-443` or `80 - 443`. Must be set together with `port_type`.",
+							Optional:            true,
+							Validators: []validator.String{
+								isPortRange(),
+								stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("port_type")),
+							},
+						},
+						"id": schema.StringAttribute{
+							MarkdownDescription: "Rule identifier",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *RuleSetResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fz.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
+
+func (r *RuleSetResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data RuleSetResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() || data.Rules.IsNull() || data.Rules.IsUnknown() {
+		return
+	}
+
+	var entries []RuleSetEntryModel
+
+	resp.Diagnostics.Append(data.Rules.ElementsAs(ctx, &entries, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	seen := map[string]int{}
+
+	for i, entry := range entries {
+		key, ok := entry.key()
+
+		if !ok {
+			continue
+		}
+
+		// Firezone would create the same rule twice, which the rule set
+		// could not tell apart.
+		if j, ok := seen[key]; ok {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("rule").AtListIndex(i),
+				"Duplicate Rule",
+				fmt.Sprintf("Rule %d is the same as rule %d.", i, j),
+			)
+			continue
+		}
+
+		seen[key] = i
+
+		if entry.Action.ValueString() != "accept" {
+			continue
+		}
+
+		if network, ok := parseRuleDestination(entry.Destination.ValueString()); ok && isBroadNetwork(network) {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("rule").AtListIndex(i).AtName("destination"),
+				"Broad rule destination",
+				fmt.Sprintf("The rule accepts traffic to %s, which covers a large part of the address space. "+
+					"Prefer more specific networks unless this is intended.", network),
+			)
+		}
+	}
+}
+
+func (r *RuleSetResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Rules only keep their ids when the rule set is updated.
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, state RuleSetResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+
+	// A replaced rule set creates all of its rules again.
+	if resp.Diagnostics.HasError() || plan.Rules.IsUnknown() || len(resp.RequiresReplace) > 0 {
+		return
+	}
+
+	planned, diags := ruleSetEntries(ctx, plan.Rules)
+	resp.Diagnostics.Append(diags...)
+
+	current, diags := ruleSetEntries(ctx, state.Rules)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Planned rules which are already in the rule set keep their ids, the
+	// others are created.
+	ids := map[string][]string{}
+
+	for _, entry := range current {
+		if key, ok := entry.key(); ok {
+			ids[key] = append(ids[key], entry.Id.ValueString())
+		}
+	}
+
+	for i := range planned {
+		key, ok := planned[i].key()
+
+		if !ok || len(ids[key]) == 0 {
+			planned[i].Id = types.StringUnknown()
+			continue
+		}
+
+		planned[i].Id = types.StringValue(ids[key][0])
+		ids[key] = ids[key][1:]
+	}
+
+	plan.Rules, diags = types.ListValueFrom(ctx, ruleSetEntryType, planned)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+func (r *RuleSetResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *RuleSetResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	planned, diags := ruleSetEntries(ctx, data.Rules)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = types.StringValue(data.scope())
+
+	// Rules created before an error are saved, so they are not created again.
+	entries := r.createRules(data, planned, []RuleSetEntryModel{}, &resp.Diagnostics)
+
+	if !resp.Diagnostics.HasError() && data.Exclusive.ValueBool() {
+		r.deleteUnmanagedRules(data, entries, &resp.Diagnostics)
+	}
+
+	data.Rules, diags = types.ListValueFrom(ctx, ruleSetEntryType, entries)
+	resp.Diagnostics.Append(diags...)
+
+	// Write logs using the tflog package
+	// Documentation: https://terraform.io/plugin/log
+	tflog.Trace(ctx, "created a resource", map[string]interface{}{"rules": len(entries)})
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RuleSetResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *RuleSetResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, diags := ruleSetEntries(ctx, data.Rules)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	rules, err := r.client.GetAllRules()
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read rules, got error: %s", err))
+		return
+	}
+
+	byID := map[string]*fz.Rule{}
+
+	for i := range *rules {
+		rule := &(*rules)[i]
+
+		if data.inScope(rule) {
+			byID[rule.ID] = rule
+		}
+	}
+
+	// Rules deleted outside of Terraform are dropped, so they are created
+	// again.
+	entries := []RuleSetEntryModel{}
+
+	for _, entry := range current {
+		rule, ok := byID[entry.Id.ValueString()]
+
+		if !ok {
+			tflog.Warn(ctx, "rule not found, removing from rule set", map[string]interface{}{"id": entry.Id.ValueString()})
+			continue
+		}
+
+		entry.fromRule(rule)
+		entries = append(entries, entry)
+		delete(byID, rule.ID)
+	}
+
+	// Exclusive rule sets show other rules in their scope, so they are
+	// deleted.
+	if data.Exclusive.ValueBool() {
+		for i := range *rules {
+			rule := &(*rules)[i]
+
+			if _, ok := byID[rule.ID]; !ok {
+				continue
+			}
+
+			var entry RuleSetEntryModel
+			entry.fromRule(rule)
+			entries = append(entries, entry)
+		}
+	}
+
+	data.Rules, diags = types.ListValueFrom(ctx, ruleSetEntryType, entries)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RuleSetResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, state *RuleSetResourceModel
+
+	// Read Terraform plan and prior state data into the models
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	planned, diags := ruleSetEntries(ctx, data.Rules)
+	resp.Diagnostics.Append(diags...)
+
+	current, diags := ruleSetEntries(ctx, state.Rules)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The plan keeps the ids of rules which did not change, see ModifyPlan.
+	kept := map[string]bool{}
+	entries := []RuleSetEntryModel{}
+
+	for _, entry := range planned {
+		if isKnown(entry.Id) {
+			kept[entry.Id.ValueString()] = true
+			entries = append(entries, entry)
+		}
+	}
+
+	var create []RuleSetEntryModel
+
+	for _, entry := range planned {
+		if !isKnown(entry.Id) {
+			create = append(create, entry)
+		}
+	}
+
+	// New rules are created before the removed ones are deleted, so traffic
+	// a changed drop rule blocks is never let through in between.
+	entries = r.createRules(data, create, entries, &resp.Diagnostics)
+
+	for _, entry := range current {
+		if kept[entry.Id.ValueString()] {
+			continue
+		}
+
+		// Rules which were not deleted stay in the rule set.
+		if resp.Diagnostics.HasError() {
+			entries = append(entries, entry)
+			continue
+		}
+
+		if err := r.client.DeleteRule(entry.Id.ValueString()); err != nil && !isNotFound(err) {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete rule %s, got error: %s", entry.Id.ValueString(), err))
+			entries = append(entries, entry)
+		}
+	}
+
+	entries = orderRuleSetEntries(planned, entries)
+
+	if !resp.Diagnostics.HasError() && data.Exclusive.ValueBool() {
+		r.deleteUnmanagedRules(data, entries, &resp.Diagnostics)
+	}
+
+	data.Rules, diags = types.ListValueFrom(ctx, ruleSetEntryType, entries)
+	resp.Diagnostics.Append(diags...)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RuleSetResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data *RuleSetResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	entries, diags := ruleSetEntries(ctx, data.Rules)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, entry := range entries {
+		err := r.client.DeleteRule(entry.Id.ValueString())
+
+		// A rule which is already gone is as good as deleted.
+		if isNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete rule %s, got error: %s", entry.Id.ValueString(), err))
+			return
+		}
+	}
+}
+
+// createRules creates rules and appends them with their ids to entries.
+// It stops at the first error, so the returned entries are the rules which
+// exist.
+func (r *RuleSetResource) createRules(data *RuleSetResourceModel, create []RuleSetEntryModel, entries []RuleSetEntryModel, diags *diag.Diagnostics) []RuleSetEntryModel {
+	for _, entry := range create {
+		rule, err := createRule(r.client, fz.Rule{
+			UserId:      data.UserId.ValueString(),
+			Action:      entry.Action.ValueString(),
+			Destination: entry.Destination.ValueString(),
+			PortRange:   entry.PortRange.ValueString(),
+			PortType:    entry.PortType.ValueString(),
+		})
+
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to create rule, got error: %s", err))
+			return entries
+		}
+
+		entry.Id = types.StringValue(rule.ID)
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// deleteUnmanagedRules deletes the rules in the scope of an exclusive rule
+// set which are not among entries.
+func (r *RuleSetResource) deleteUnmanagedRules(data *RuleSetResourceModel, entries []RuleSetEntryModel, diags *diag.Diagnostics) {
+	rules, err := r.client.GetAllRules()
+
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to read rules, got error: %s", err))
+		return
+	}
+
+	managed := map[string]bool{}
+
+	for _, entry := range entries {
+		managed[entry.Id.ValueString()] = true
+	}
+
+	for _, rule := range *rules {
+		if !data.inScope(&rule) || managed[rule.ID] {
+			continue
+		}
+
+		if err := r.client.DeleteRule(rule.ID); err != nil && !isNotFound(err) {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to delete unmanaged rule %s, got error: %s", rule.ID, err))
+			return
+		}
+	}
+}
+
+// scope returns the user id of the rule set, or global for global rules.
+func (m *RuleSetResourceModel) scope() string {
+	if m.UserId.IsNull() || m.UserId.ValueString() == "" {
+		return "global"
+	}
+	return m.UserId.ValueString()
+}
+
+// inScope reports whether a rule belongs to the user of the rule set, or
+// is global for global rule sets.
+func (m *RuleSetResourceModel) inScope(rule *fz.Rule) bool {
+	return rule.UserId == m.UserId.ValueString()
+}
+
+// fromRule copies a Firezone rule into the entry. The configured
+// destination and port range are kept when they are the same as
+// Firezone's.
+func (e *RuleSetEntryModel) fromRule(rule *fz.Rule) {
+	e.Id = types.StringValue(rule.ID)
+	e.Action = types.StringValue(rule.Action)
+	e.PortType = optionalString(rule.PortType)
+
+	if !sameRuleDestination(e.Destination.ValueString(), rule.Destination) {
+		e.Destination = DestinationStringValue(rule.Destination)
+	}
+
+	if !samePortRange(e.PortRange.ValueString(), rule.PortRange) {
+		e.PortRange = optionalString(rule.PortRange)
+	}
+}
+
+// key identifies the rule an entry describes, so the same rule written
+// differently has the same key. It is false when a field is unknown.
+func (e *RuleSetEntryModel) key() (string, bool) {
+	for _, value := range []attr.Value{e.Action, e.Destination, e.PortType, e.PortRange} {
+		if value.IsUnknown() {
+			return "", false
+		}
+	}
+
+	destination := e.Destination.ValueString()
+
+	if network, ok := parseRuleDestination(destination); ok {
+		destination = network.String()
+	}
+
+	return fmt.Sprintf("%s/%s/%s/%s", e.Action.ValueString(), destination, e.PortType.ValueString(), canonicalPortRange(e.PortRange.ValueString())), true
+}
+
+// ruleSetEntries returns the entries of a rule set list.
+func ruleSetEntries(ctx context.Context, rules types.List) ([]RuleSetEntryModel, diag.Diagnostics) {
+	var entries []RuleSetEntryModel
+
+	if rules.IsNull() || rules.IsUnknown() {
+		return entries, nil
+	}
+
+	diags := rules.ElementsAs(ctx, &entries, false)
+
+	return entries, diags
+}
+
+// orderRuleSetEntries sorts entries in the order of the planned entries
+// they were created from, so the state matches the configuration.
+func orderRuleSetEntries(planned []RuleSetEntryModel, entries []RuleSetEntryModel) []RuleSetEntryModel {
+	ordered := make([]RuleSetEntryModel, 0, len(entries))
+	used := make([]bool, len(entries))
+
+	for _, p := range planned {
+		for i, entry := range entries {
+			if used[i] || !sameRuleSetEntry(p, entry) {
+				continue
+			}
+
+			ordered = append(ordered, entry)
+			used[i] = true
+			break
+		}
+	}
+
+	for i, entry := range entries {
+		if !used[i] {
+			ordered = append(ordered, entry)
+		}
+	}
+
+	return ordered
+}
+
+// sameRuleSetEntry reports whether two entries describe the same rule.
+func sameRuleSetEntry(a RuleSetEntryModel, b RuleSetEntryModel) bool {
+	keyA, okA := a.key()
+	keyB, okB := b.key()
+
+	return okA && okB && keyA == keyB
+}
@@ -0,0 +1,380 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccRuleSetResource(t *testing.T) {
+	var ssh, https string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckRuleSetResourceDestroy,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: providerConfig + testAccRuleSetResourceConfig(`
+  rule {
+    action      = "accept"
+    destination = "10.0.0.1"
+    port_type   = "tcp"
+    port_range  = "22"
+  }
+
+  rule {
+    action      = "accept"
+    destination = "10.1.0.0/16"
+    port_type   = "tcp"
+    port_range  = "80-443"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("firezone_rule_set.test", "id", "firezone_user.test", "id"),
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "exclusive", "false"),
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "rule.#", "2"),
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "rule.0.destination", "10.0.0.1"),
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "rule.1.port_range", "80-443"),
+					testAccCheckSameAttr("firezone_rule_set.test", "rule.0.id", &ssh),
+					testAccCheckSameAttr("firezone_rule_set.test", "rule.1.id", &https),
+					testAccCheckRuleSetRules("firezone_rule_set.test", 2),
+				),
+			},
+			// Writing rules differently and reordering them is not a change.
+			{
+				Config: providerConfig + testAccRuleSetResourceConfig(`
+  rule {
+    action      = "accept"
+    destination = "10.1.0.0/16"
+    port_type   = "tcp"
+    port_range  = "80 - 443"
+  }
+
+  rule {
+    action      = "accept"
+    destination = "10.0.0.1/32"
+    port_type   = "tcp"
+    port_range  = "22"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "rule.#", "2"),
+					testAccCheckSameAttr("firezone_rule_set.test", "rule.0.id", &https),
+					testAccCheckSameAttr("firezone_rule_set.test", "rule.1.id", &ssh),
+					testAccCheckRuleSetRules("firezone_rule_set.test", 2),
+				),
+			},
+			// Only the changed rules are created and deleted.
+			{
+				Config: providerConfig + testAccRuleSetResourceConfig(`
+  rule {
+    action      = "accept"
+    destination = "10.1.0.0/16"
+    port_type   = "tcp"
+    port_range  = "80 - 443"
+  }
+
+  rule {
+    action      = "drop"
+    destination = "10.2.0.0/16"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "rule.#", "2"),
+					testAccCheckSameAttr("firezone_rule_set.test", "rule.0.id", &https),
+					resource.TestCheckResourceAttrSet("firezone_rule_set.test", "rule.1.id"),
+					resource.TestCheckNoResourceAttr("firezone_rule_set.test", "rule.1.port_type"),
+					testAccCheckRuleDeleted(&ssh),
+					testAccCheckRuleSetRules("firezone_rule_set.test", 2),
+				),
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
+
+func TestAccRuleSetResource_changedDrop(t *testing.T) {
+	var userID string
+	var missing []string
+
+	config := func(destination string) string {
+		return providerConfig + testAccRuleSetResourceConfig(fmt.Sprintf(`
+  rule {
+    action      = "drop"
+    destination = %q
+  }
+`, destination))
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckRuleSetResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config("10.0.0.0/8"),
+				Check: func(s *terraform.State) error {
+					userID = s.RootModule().Resources["firezone_rule_set.test"].Primary.Attributes["user_id"]
+					return nil
+				},
+			},
+			// The user's traffic is dropped at every point of the change.
+			{
+				PreConfig: func() {
+					testAccFirezone.watchRules(func(rules []fakeRule) {
+						for _, rule := range rules {
+							if rule.UserID != nil && *rule.UserID == userID && rule.Action == "drop" {
+								return
+							}
+						}
+						missing = append(missing, fmt.Sprintf("%d rules", len(rules)))
+					})
+				},
+				Config: config("10.0.0.0/16"),
+				Check: func(s *terraform.State) error {
+					testAccFirezone.watchRules(nil)
+					if len(missing) > 0 {
+						return fmt.Errorf("no drop rule existed while the rule set was changed, with %v", missing)
+					}
+					return testAccCheckRuleSetRules("firezone_rule_set.test", 1)(s)
+				},
+			},
+		},
+	})
+}
+
+func TestAccRuleSetResource_exclusive(t *testing.T) {
+	var unmanaged string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckRuleSetResourceDestroy,
+		Steps: []resource.TestStep{
+			// Global rules which are not in the rule set are deleted.
+			{
+				PreConfig: func() {
+					unmanaged = testAccFirezone.addRule("accept", "192.168.0.0/16")
+				},
+				Config: providerConfig + testAccRuleSetResourceGlobalConfig(true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "id", "global"),
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "rule.#", "1"),
+					testAccCheckRuleDeleted(&unmanaged),
+					testAccCheckRuleSetRules("firezone_rule_set.test", 1),
+				),
+			},
+			// Rules created later show up as drift.
+			{
+				PreConfig: func() {
+					testAccFirezone.addRule("accept", "192.168.0.0/16")
+				},
+				Config:             providerConfig + testAccRuleSetResourceGlobalConfig(true),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: providerConfig + testAccRuleSetResourceGlobalConfig(true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "rule.#", "1"),
+					testAccCheckRuleSetRules("firezone_rule_set.test", 1),
+				),
+			},
+			// Non-exclusive rule sets leave them alone.
+			{
+				Config: providerConfig + testAccRuleSetResourceGlobalConfig(false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "exclusive", "false"),
+					testAccCheckRuleSetRules("firezone_rule_set.test", 1),
+				),
+			},
+			{
+				PreConfig: func() {
+					testAccFirezone.addRule("accept", "192.168.0.0/16")
+				},
+				Config: providerConfig + testAccRuleSetResourceGlobalConfig(false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_rule_set.test", "rule.#", "1"),
+					testAccCheckRuleSetRules("firezone_rule_set.test", 2),
+				),
+			},
+		},
+	})
+}
+
+func TestAccRuleSetResource_disappears(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckRuleSetResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccRuleSetResourceGlobalConfig(false),
+				Check: func(s *terraform.State) error {
+					testAccFirezone.deleteRule(s.RootModule().Resources["firezone_rule_set.test"].Primary.Attributes["rule.0.id"])
+					return nil
+				},
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccRuleSetResource_duplicate(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "firezone_rule_set" "test" {
+  rule {
+    action      = "drop"
+    destination = "10.0.0.0/8"
+  }
+
+  rule {
+    action      = "drop"
+    destination = "10.1.2.3/8"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`Rule 1 is the same as rule 0`),
+			},
+		},
+	})
+}
+
+func TestRuleSetEntryKey(t *testing.T) {
+	entry := func(action string, destination string, portType string, portRange string) RuleSetEntryModel {
+		return RuleSetEntryModel{
+			Action:      types.StringValue(action),
+			Destination: DestinationStringValue(destination),
+			PortType:    optionalString(portType),
+			PortRange:   optionalString(portRange),
+		}
+	}
+
+	same := [][2]RuleSetEntryModel{
+		{entry("drop", "10.0.0.1", "tcp", "80-443"), entry("drop", "10.0.0.1/32", "tcp", "80 - 443")},
+		{entry("drop", "10.1.2.3/8", "", ""), entry("drop", "10.0.0.0/8", "", "")},
+		{entry("accept", "2001:db8::1", "udp", "53-53"), entry("accept", "2001:db8::1/128", "udp", "53")},
+	}
+
+	for _, pair := range same {
+		if !sameRuleSetEntry(pair[0], pair[1]) {
+			t.Errorf("%v, %v: expected them to be the same", pair[0], pair[1])
+		}
+	}
+
+	different := [][2]RuleSetEntryModel{
+		{entry("drop", "10.0.0.0/8", "", ""), entry("accept", "10.0.0.0/8", "", "")},
+		{entry("drop", "10.0.0.0/8", "tcp", "22"), entry("drop", "10.0.0.0/8", "udp", "22")},
+		{entry("drop", "10.0.0.0/8", "tcp", "22"), entry("drop", "10.0.0.0/8", "", "")},
+		{entry("drop", "10.0.0.0/8", "", ""), entry("drop", "10.0.0.0/16", "", "")},
+	}
+
+	for _, pair := range different {
+		if sameRuleSetEntry(pair[0], pair[1]) {
+			t.Errorf("%v, %v: expected them to be different", pair[0], pair[1])
+		}
+	}
+
+	unknown := entry("drop", "10.0.0.0/8", "", "")
+	unknown.Destination = DestinationValue{StringValue: types.StringUnknown()}
+
+	if sameRuleSetEntry(unknown, unknown) {
+		t.Errorf("expected entries with unknown fields to never be the same")
+	}
+}
+
+// testAccCheckSameAttr records an attribute of a resource in value the first
+// time it is called and checks it is unchanged on later calls.
+func testAccCheckSameAttr(name string, key string, value *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+		if *value == "" {
+			*value = rs.Primary.Attributes[key]
+			return nil
+		}
+		if rs.Primary.Attributes[key] != *value {
+			return fmt.Errorf("%s.%s changed from %s to %s", name, key, *value, rs.Primary.Attributes[key])
+		}
+		return nil
+	}
+}
+
+// testAccCheckRuleDeleted checks the rule with the id recorded in id is
+// gone.
+func testAccCheckRuleDeleted(id *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if testAccFirezone.rule(*id) != nil {
+			return fmt.Errorf("rule %s still exists", *id)
+		}
+		return nil
+	}
+}
+
+// testAccCheckRuleSetRules checks the user of a rule set, or the global
+// rules, have n rules.
+func testAccCheckRuleSetRules(name string, n int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+		if count := testAccFirezone.ruleCount(rs.Primary.Attributes["user_id"]); count != n {
+			return fmt.Errorf("expected %d rules, got %d", n, count)
+		}
+		return nil
+	}
+}
+
+func testAccCheckRuleSetResourceDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "firezone_rule_set" {
+			continue
+		}
+		for key, id := range rs.Primary.Attributes {
+			if regexp.MustCompile(`^rule\.\d+\.id$`).MatchString(key) && testAccFirezone.rule(id) != nil {
+				return fmt.Errorf("rule %s still exists", id)
+			}
+		}
+	}
+	return nil
+}
+
+func testAccRuleSetResourceConfig(rules string) string {
+	return fmt.Sprintf(`
+resource "firezone_user" "test" {
+  email = "rule-set@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_rule_set" "test" {
+  user_id = firezone_user.test.id
+%s}
+`, rules)
+}
+
+func testAccRuleSetResourceGlobalConfig(exclusive bool) string {
+	return fmt.Sprintf(`
+resource "firezone_rule_set" "test" {
+  exclusive = %t
+
+  rule {
+    action      = "drop"
+    destination = "10.0.0.0/8"
+  }
+}
+`, exclusive)
+}
@@ -0,0 +1,224 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &RulesDataSource{}
+
+func NewRulesDataSource() datasource.DataSource {
+	return &RulesDataSource{}
+}
+
+// RulesDataSource defines the data source implementation.
+type RulesDataSource struct {
+	client *fz.Client
+}
+
+// RulesDataSourceModel describes the data source data model. The rules
+// have the same attributes as the rule resource.
+type RulesDataSourceModel struct {
+	Id          types.String        `tfsdk:"id"`
+	UserId      types.String        `tfsdk:"user_id"`
+	Action      types.String        `tfsdk:"action"`
+	Destination types.String        `tfsdk:"destination"`
+	Rules       []RuleResourceModel `tfsdk:"rules"`
+}
+
+func (d *RulesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rules"
+}
+
+func (d *RulesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Rules data source. Lists all egress rules matching every given filter.",
+
+		Attributes: map[string]schema.Attribute{
+			"user_id": schema.StringAttribute{
+				MarkdownDescription: "Only list rules of this user",
+				Optional:            true,
+			},
+			"action": schema.StringAttribute{
+				MarkdownDescription: "Only list rules with this action, either accept or drop",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(
+						regexp.MustCompile(`^(accept|drop)$`),
+						"must be either 'accept' or 'drop'",
+					),
+				},
+			},
+			"destination": schema.StringAttribute{
+				MarkdownDescription: "Only list rules with a destination overlapping this IPv4 or IPv6 network",
+				Optional:            true,
+				Validators: []validator.String{
+					isCIDR(),
+				},
+			},
+			"rules": schema.ListNestedAttribute{
+				MarkdownDescription: "Matching rules, sorted by destination",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "Rule identifier",
+							Computed:            true,
+						},
+						"user_id": schema.StringAttribute{
+							MarkdownDescription: "Rule user id, null for rules applying to all users",
+							Computed:            true,
+						},
+						"action": schema.StringAttribute{
+							MarkdownDescription: "Rule action",
+							Computed:            true,
+						},
+						"destination": schema.StringAttribute{
+							MarkdownDescription: "Rule destination",
+							Computed:            true,
+							CustomType:          DestinationType{},
+						},
+						"port_type": schema.StringAttribute{
+							MarkdownDescription: "Rule port type, null for rules matching all protocols",
+							Computed:            true,
+						},
+						"port_range": schema.StringAttribute{
+							MarkdownDescription: "Rule port range, null for rules matching all ports",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Data source identifier",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *RulesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fz.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *RulesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data RulesDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var destination *netip.Prefix
+
+	if !data.Destination.IsNull() {
+		prefix, err := netip.ParsePrefix(data.Destination.ValueString())
+
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("destination"), "Invalid Attribute Value", err.Error())
+			return
+		}
+
+		prefix = prefix.Masked()
+		destination = &prefix
+	}
+
+	rules, err := d.client.GetAllRules()
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read rules, got error: %s", err))
+		return
+	}
+
+	data.Rules = []RuleResourceModel{}
+
+	for i := range *rules {
+		rule := &(*rules)[i]
+
+		if !data.UserId.IsNull() && rule.UserId != data.UserId.ValueString() {
+			continue
+		}
+
+		if !data.Action.IsNull() && rule.Action != data.Action.ValueString() {
+			continue
+		}
+
+		if destination != nil {
+			network, ok := parseRuleDestination(rule.Destination)
+
+			if !ok || !network.Overlaps(*destination) {
+				continue
+			}
+		}
+
+		var element RuleResourceModel
+		element.fromRule(rule)
+		data.Rules = append(data.Rules, element)
+	}
+
+	sort.SliceStable(data.Rules, func(i, j int) bool {
+		a, b := data.Rules[i], data.Rules[j]
+
+		if a.Destination.ValueString() != b.Destination.ValueString() {
+			return a.Destination.ValueString() < b.Destination.ValueString()
+		}
+		if a.PortType.ValueString() != b.PortType.ValueString() {
+			return a.PortType.ValueString() < b.PortType.ValueString()
+		}
+		return a.PortRange.ValueString() < b.PortRange.ValueString()
+	})
+
+	data.Id = types.StringValue("rules")
+
+	// Write logs using the tflog package
+	// Documentation: https://terraform.io/plugin/log
+	tflog.Trace(ctx, "read a data source")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// parseRuleDestination parses a rule destination, which is a network in
+// CIDR notation or a single address.
+func parseRuleDestination(destination string) (netip.Prefix, bool) {
+	if prefix, err := netip.ParsePrefix(destination); err == nil {
+		return prefix.Masked(), true
+	}
+	if addr, err := netip.ParseAddr(destination); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), true
+	}
+	return netip.Prefix{}, false
+}
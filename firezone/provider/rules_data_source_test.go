@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccRulesDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: providerConfig + testAccRulesDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.firezone_rules.by_user", "id", "rules"),
+					resource.TestCheckResourceAttr("data.firezone_rules.by_user", "rules.#", "2"),
+					resource.TestCheckResourceAttrPair("data.firezone_rules.by_user", "rules.0.id", "firezone_rule.web", "id"),
+					resource.TestCheckResourceAttrPair("data.firezone_rules.by_user", "rules.0.user_id", "firezone_user.frank", "id"),
+					resource.TestCheckResourceAttr("data.firezone_rules.by_user", "rules.0.action", "accept"),
+					resource.TestCheckResourceAttr("data.firezone_rules.by_user", "rules.0.destination", "198.51.100.0/26"),
+					resource.TestCheckResourceAttr("data.firezone_rules.by_user", "rules.0.port_type", "tcp"),
+					resource.TestCheckResourceAttr("data.firezone_rules.by_user", "rules.0.port_range", "443"),
+					resource.TestCheckResourceAttrPair("data.firezone_rules.by_user", "rules.1.id", "firezone_rule.dns", "id"),
+
+					resource.TestCheckResourceAttr("data.firezone_rules.drops", "rules.#", "1"),
+					resource.TestCheckResourceAttrPair("data.firezone_rules.drops", "rules.0.id", "firezone_rule.dns", "id"),
+
+					resource.TestCheckResourceAttr("data.firezone_rules.overlapping", "rules.#", "2"),
+					resource.TestCheckResourceAttrPair("data.firezone_rules.overlapping", "rules.0.id", "firezone_rule.global", "id"),
+					resource.TestCheckNoResourceAttr("data.firezone_rules.overlapping", "rules.0.user_id"),
+					resource.TestCheckResourceAttrPair("data.firezone_rules.overlapping", "rules.1.id", "firezone_rule.dns", "id"),
+
+					resource.TestCheckResourceAttr("data.firezone_rules.none", "rules.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccRulesDataSource_invalidFilters(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+data "firezone_rules" "test" {
+  action = "reject"
+}
+`,
+				ExpectError: regexp.MustCompile(`must\s+be\s+either\s+'accept'\s+or\s+'drop'`),
+			},
+			{
+				Config: providerConfig + `
+data "firezone_rules" "test" {
+  destination = "198.51.100.1"
+}
+`,
+				ExpectError: regexp.MustCompile(`must\s+be\s+an\s+IPv4\s+or\s+IPv6\s+network`),
+			},
+		},
+	})
+}
+
+const testAccRulesDataSourceConfig = `
+resource "firezone_user" "frank" {
+  email = "frank@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_rule" "web" {
+  user_id     = firezone_user.frank.id
+  action      = "accept"
+  destination = "198.51.100.0/26"
+  port_range  = "443"
+  port_type   = "tcp"
+}
+
+resource "firezone_rule" "dns" {
+  user_id     = firezone_user.frank.id
+  action      = "drop"
+  destination = "198.51.100.200/32"
+  port_range  = "53"
+  port_type   = "udp"
+}
+
+resource "firezone_rule" "global" {
+  action      = "accept"
+  destination = "198.51.100.128/25"
+  port_range  = "80"
+  port_type   = "tcp"
+}
+
+data "firezone_rules" "by_user" {
+  user_id    = firezone_user.frank.id
+  depends_on = [firezone_rule.web, firezone_rule.dns, firezone_rule.global]
+}
+
+data "firezone_rules" "drops" {
+  user_id    = firezone_user.frank.id
+  action     = "drop"
+  depends_on = [firezone_rule.web, firezone_rule.dns, firezone_rule.global]
+}
+
+data "firezone_rules" "overlapping" {
+  destination = "198.51.100.192/26"
+  depends_on  = [firezone_rule.web, firezone_rule.dns, firezone_rule.global]
+}
+
+data "firezone_rules" "none" {
+  user_id     = firezone_user.frank.id
+  destination = "203.0.113.0/24"
+  depends_on  = [firezone_rule.web, firezone_rule.dns, firezone_rule.global]
+}
+`
@@ -0,0 +1,347 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &SAMLProviderResource{}
+var _ resource.ResourceWithImportState = &SAMLProviderResource{}
+
+func NewSAMLProviderResource() resource.Resource {
+	return &SAMLProviderResource{}
+}
+
+// SAMLProviderResource defines the resource implementation.
+type SAMLProviderResource struct {
+	client *fz.Client
+}
+
+// SAMLProviderResourceModel describes the resource data model.
+type SAMLProviderResourceModel struct {
+	Id                    types.String `tfsdk:"id"`
+	Label                 types.String `tfsdk:"label"`
+	BaseURL               types.String `tfsdk:"base_url"`
+	Metadata              types.String `tfsdk:"metadata"`
+	SignRequests          types.Bool   `tfsdk:"sign_requests"`
+	SignMetadata          types.Bool   `tfsdk:"sign_metadata"`
+	SignedAssertionInResp types.Bool   `tfsdk:"signed_assertion_in_resp"`
+	SignedEnvelopesInResp types.Bool   `tfsdk:"signed_envelopes_in_resp"`
+	AutoCreateUsers       types.Bool   `tfsdk:"auto_create_users"`
+}
+
+// firezoneSAMLProvider is a SAML identity provider entry of the Firezone
+// configuration.
+type firezoneSAMLProvider struct {
+	ID                    string `json:"id"`
+	Label                 string `json:"label"`
+	BaseURL               string `json:"base_url,omitempty"`
+	Metadata              string `json:"metadata"`
+	SignRequests          bool   `json:"sign_requests"`
+	SignMetadata          bool   `json:"sign_metadata"`
+	SignedAssertionInResp bool   `json:"signed_assertion_in_resp"`
+	SignedEnvelopesInResp bool   `json:"signed_envelopes_in_resp"`
+	AutoCreateUsers       bool   `json:"auto_create_users"`
+}
+
+func (r *SAMLProviderResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_saml_provider"
+}
+
+func (r *SAMLProviderResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "SAML identity provider. Other identity providers in the Firezone configuration are left as they are.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "SAML provider identifier, used in the sign in URL `/auth/saml/<id>`",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(
+						identityProviderIDRegexp,
+						"must only contain letters, digits, '_' and '-'",
+					),
+				},
+			},
+			"label": schema.StringAttribute{
+				MarkdownDescription: "SAML provider label shown on the sign in page",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"base_url": schema.StringAttribute{
+				MarkdownDescription: "Base URL of the Firezone SAML endpoints, defaults to the one derived from the Firezone URL",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"metadata": schema.StringAttribute{
+				MarkdownDescription: "SAML metadata XML document of the identity provider",
+				Required:            true,
+				Validators: []validator.String{
+					isXML(),
+				},
+			},
+			"sign_requests": schema.BoolAttribute{
+				MarkdownDescription: "Sign SAML requests",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"sign_metadata": schema.BoolAttribute{
+				MarkdownDescription: "Sign the Firezone service provider metadata",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"signed_assertion_in_resp": schema.BoolAttribute{
+				MarkdownDescription: "Require the assertions in SAML responses to be signed",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"signed_envelopes_in_resp": schema.BoolAttribute{
+				MarkdownDescription: "Require the envelopes of SAML responses to be signed",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"auto_create_users": schema.BoolAttribute{
+				MarkdownDescription: "Create users signing in with this provider for the first time",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+		},
+	}
+}
+
+func (r *SAMLProviderResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fz.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
+
+func (r *SAMLProviderResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *SAMLProviderResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id := data.Id.ValueString()
+
+	configuration, err := modifyIdentityProviders(r.client, samlProvidersField, func(entries []json.RawMessage) ([]json.RawMessage, error) {
+		if exists, _ := findIdentityProvider(entries, id, &firezoneSAMLProvider{}); exists {
+			return nil, errIdentityProviderExists(id)
+		}
+		return putIdentityProvider(entries, id, data.toSAMLProvider())
+	})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create SAML provider, got error: %s", err))
+		return
+	}
+
+	provider, err := findSAMLProvider(configuration, id)
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read created SAML provider, got error: %s", err))
+		return
+	}
+
+	if provider == nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("SAML provider %s is missing after it was created", id))
+		return
+	}
+
+	data.fromSAMLProvider(provider)
+
+	// Write logs using the tflog package
+	// Documentation: https://terraform.io/plugin/log
+	tflog.Trace(ctx, "created a resource")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *SAMLProviderResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *SAMLProviderResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	configuration, err := getConfiguration(r.client)
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read SAML provider, got error: %s", err))
+		return
+	}
+
+	provider, err := findSAMLProvider(configuration, data.Id.ValueString())
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read SAML provider, got error: %s", err))
+		return
+	}
+
+	if provider == nil {
+		// The provider was deleted outside of Terraform, e.g. in the admin UI.
+		tflog.Warn(ctx, "SAML provider not found, removing from state", map[string]interface{}{"id": data.Id.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	data.fromSAMLProvider(provider)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *SAMLProviderResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *SAMLProviderResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id := data.Id.ValueString()
+
+	configuration, err := modifyIdentityProviders(r.client, samlProvidersField, func(entries []json.RawMessage) ([]json.RawMessage, error) {
+		return putIdentityProvider(entries, id, data.toSAMLProvider())
+	})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update SAML provider, got error: %s", err))
+		return
+	}
+
+	provider, err := findSAMLProvider(configuration, id)
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read updated SAML provider, got error: %s", err))
+		return
+	}
+
+	if provider == nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("SAML provider %s is missing after it was updated", id))
+		return
+	}
+
+	data.fromSAMLProvider(provider)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *SAMLProviderResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data *SAMLProviderResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, err := modifyIdentityProviders(r.client, samlProvidersField, func(entries []json.RawMessage) ([]json.RawMessage, error) {
+		return removeIdentityProvider(entries, data.Id.ValueString()), nil
+	})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete SAML provider, got error: %s", err))
+		return
+	}
+}
+
+func (r *SAMLProviderResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// findSAMLProvider returns the SAML provider with id from configuration,
+// or nil if there is none.
+func findSAMLProvider(configuration *firezoneConfiguration, id string) (*firezoneSAMLProvider, error) {
+	var provider firezoneSAMLProvider
+
+	exists, err := findIdentityProvider(configuration.SAMLIdentityProviders, id, &provider)
+
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	return &provider, nil
+}
+
+// toSAMLProvider converts the model into a Firezone SAML provider entry.
+func (m *SAMLProviderResourceModel) toSAMLProvider() firezoneSAMLProvider {
+	return firezoneSAMLProvider{
+		ID:                    m.Id.ValueString(),
+		Label:                 m.Label.ValueString(),
+		BaseURL:               m.BaseURL.ValueString(),
+		Metadata:              m.Metadata.ValueString(),
+		SignRequests:          m.SignRequests.ValueBool(),
+		SignMetadata:          m.SignMetadata.ValueBool(),
+		SignedAssertionInResp: m.SignedAssertionInResp.ValueBool(),
+		SignedEnvelopesInResp: m.SignedEnvelopesInResp.ValueBool(),
+		AutoCreateUsers:       m.AutoCreateUsers.ValueBool(),
+	}
+}
+
+// fromSAMLProvider copies a Firezone SAML provider entry into the model.
+func (m *SAMLProviderResourceModel) fromSAMLProvider(provider *firezoneSAMLProvider) {
+	m.Id = types.StringValue(provider.ID)
+	m.Label = types.StringValue(provider.Label)
+	m.BaseURL = types.StringValue(provider.BaseURL)
+	m.Metadata = types.StringValue(provider.Metadata)
+	m.SignRequests = types.BoolValue(provider.SignRequests)
+	m.SignMetadata = types.BoolValue(provider.SignMetadata)
+	m.SignedAssertionInResp = types.BoolValue(provider.SignedAssertionInResp)
+	m.SignedEnvelopesInResp = types.BoolValue(provider.SignedEnvelopesInResp)
+	m.AutoCreateUsers = types.BoolValue(provider.AutoCreateUsers)
+}
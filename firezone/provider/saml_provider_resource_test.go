@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+const testAccSAMLMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="http://www.okta.com/exk1">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://example.okta.com/app/sso/saml"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>
+`
+
+func TestAccSAMLProviderResource(t *testing.T) {
+	t.Cleanup(testAccFirezone.resetConfiguration)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckSAMLProviderResourceDestroy,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				PreConfig: func() {
+					testAccFirezone.updateConfig(func(c *fakeConfiguration) {
+						c.SAMLIdentityProviders = append(c.SAMLIdentityProviders, json.RawMessage(
+							`{"id":"azure","label":"Azure","metadata":"<EntityDescriptor/>","extra":"kept"}`,
+						))
+					})
+				},
+				Config: providerConfig + testAccSAMLProviderResourceConfig("Okta", ""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "id", "okta"),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "label", "Okta"),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "metadata", testAccSAMLMetadata),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "base_url", "https://firezone.example.com/auth/saml"),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "sign_requests", "true"),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "sign_metadata", "true"),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "signed_assertion_in_resp", "true"),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "signed_envelopes_in_resp", "true"),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "auto_create_users", "false"),
+					testAccCheckSAMLProviderKept,
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      "firezone_saml_provider.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			// Update and Read testing
+			{
+				Config: providerConfig + testAccSAMLProviderResourceConfig("Okta SAML", `
+  sign_requests            = false
+  signed_envelopes_in_resp = false
+  auto_create_users        = true
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "label", "Okta SAML"),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "sign_requests", "false"),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "signed_envelopes_in_resp", "false"),
+					resource.TestCheckResourceAttr("firezone_saml_provider.test", "auto_create_users", "true"),
+					testAccCheckSAMLProviderKept,
+				),
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
+
+func TestAccSAMLProviderResource_invalidMetadata(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "firezone_saml_provider" "test" {
+  id       = "okta"
+  label    = "Okta"
+  metadata = "<md:EntityDescriptor><md:IDPSSODescriptor></md:EntityDescriptor>"
+}
+`,
+				ExpectError: regexp.MustCompile(`must\s+be\s+a\s+well-formed\s+XML\s+document`),
+			},
+		},
+	})
+}
+
+func TestAccSAMLProviderResource_disappears(t *testing.T) {
+	t.Cleanup(testAccFirezone.resetConfiguration)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckSAMLProviderResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + testAccSAMLProviderResourceConfig("Okta", ""),
+				Check: func(s *terraform.State) error {
+					testAccFirezone.updateConfig(func(c *fakeConfiguration) {
+						c.SAMLIdentityProviders = []json.RawMessage{}
+					})
+					return nil
+				},
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+// testAccCheckSAMLProviderKept checks that the provider which is not
+// managed by Terraform is left untouched.
+func testAccCheckSAMLProviderKept(s *terraform.State) error {
+	var provider map[string]interface{}
+
+	exists, err := findIdentityProvider(testAccFirezone.config().SAMLIdentityProviders, "azure", &provider)
+	if err != nil {
+		return err
+	}
+	if !exists || provider["extra"] != "kept" {
+		return fmt.Errorf("unmanaged SAML provider was changed: %v", provider)
+	}
+	return nil
+}
+
+func testAccCheckSAMLProviderResourceDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "firezone_saml_provider" {
+			continue
+		}
+		exists, err := findIdentityProvider(testAccFirezone.config().SAMLIdentityProviders, rs.Primary.ID, &firezoneSAMLProvider{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("SAML provider %s still exists", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccSAMLProviderResourceConfig(label string, extra string) string {
+	return fmt.Sprintf(`
+resource "firezone_saml_provider" "test" {
+  id       = "okta"
+  label    = %[1]q
+  metadata = %[2]q
+  %[3]s
+}
+`, label, testAccSAMLMetadata, extra)
+}
@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// tlsSettings holds the resolved TLS attributes of the provider.
+type tlsSettings struct {
+	CACertPEM          string
+	CACertFile         string
+	ClientCert         string
+	ClientKey          string
+	InsecureSkipVerify bool
+}
+
+// config builds the TLS configuration for the Firezone API client. It
+// returns nil when the system defaults should be used.
+func (s tlsSettings) config(diags *diag.Diagnostics) *tls.Config {
+	if s.CACertPEM == "" && s.CACertFile == "" && s.ClientCert == "" && s.ClientKey == "" && !s.InsecureSkipVerify {
+		return nil
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if s.CACertPEM != "" && s.CACertFile != "" {
+		diags.AddAttributeError(
+			path.Root("ca_cert_file"),
+			"Conflicting CA certificates",
+			"Only one of ca_cert_pem (FIREZONE_CA_CERT_PEM) and ca_cert_file (FIREZONE_CA_CERT_FILE) can be set",
+		)
+		return nil
+	}
+
+	caCertPEM, caCertPath := []byte(s.CACertPEM), path.Root("ca_cert_pem")
+
+	if s.CACertFile != "" {
+		var err error
+		caCertPath = path.Root("ca_cert_file")
+		caCertPEM, err = os.ReadFile(s.CACertFile)
+
+		if err != nil {
+			diags.AddAttributeError(caCertPath, "Unable to read CA certificate", err.Error())
+			return nil
+		}
+	}
+
+	if len(caCertPEM) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(caCertPEM) {
+			diags.AddAttributeError(caCertPath, "Invalid CA certificate", "No PEM encoded certificates found")
+			return nil
+		}
+
+		config.RootCAs = pool
+	}
+
+	if (s.ClientCert == "") != (s.ClientKey == "") {
+		diags.AddAttributeError(
+			path.Root("client_key"),
+			"Incomplete client certificate",
+			"client_cert (FIREZONE_CLIENT_CERT) and client_key (FIREZONE_CLIENT_KEY) must be set together",
+		)
+		return nil
+	}
+
+	if s.ClientCert != "" {
+		certificate, err := tls.X509KeyPair([]byte(s.ClientCert), []byte(s.ClientKey))
+
+		if err != nil {
+			diags.AddAttributeError(path.Root("client_cert"), "Invalid client certificate", err.Error())
+			return nil
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	if s.InsecureSkipVerify {
+		diags.AddAttributeWarning(
+			path.Root("insecure_skip_verify"),
+			"TLS certificate verification is disabled",
+			"The Firezone API certificate is not verified, so the API key can be intercepted. "+
+				"Only use insecure_skip_verify for testing, prefer ca_cert_pem or ca_cert_file for private CAs.",
+		)
+		config.InsecureSkipVerify = true
+	}
+
+	return config
+}
+
+// newBaseTransport returns the transport used for API requests, using
+// config for TLS connections when given.
+func newBaseTransport(config *tls.Config) http.RoundTripper {
+	if config == nil {
+		return http.DefaultTransport
+	}
+
+	defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+
+	if !ok {
+		return &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: config}
+	}
+
+	transport := defaultTransport.Clone()
+	transport.TLSClientConfig = config
+	return transport
+}
+
+// envBool parses a boolean environment variable, ignoring invalid values.
+func envBool(name string, diags *diag.Diagnostics) bool {
+	value := os.Getenv(name)
+
+	if value == "" {
+		return false
+	}
+
+	enabled, err := strconv.ParseBool(value)
+
+	if err != nil {
+		diags.AddWarning("Invalid environment variable", fmt.Sprintf("%s=%q is not a boolean and is ignored", name, value))
+		return false
+	}
+
+	return enabled
+}
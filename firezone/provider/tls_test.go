@@ -0,0 +1,224 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccProvider_tls(t *testing.T) {
+	server := httptest.NewTLSServer(testAccFirezone)
+	t.Cleanup(server.Close)
+
+	caCertPEM := testCertificatePEM(server.Certificate().Raw)
+	caCertFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caCertFile, []byte(caCertPEM), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// The server certificate is not trusted by default
+			{
+				Config:      testAccProviderTLSConfig(server.URL, "") + testAccProviderTLSUserConfig,
+				ExpectError: regexp.MustCompile(`TLS connection to firezone endpoint failed`),
+			},
+			{
+				Config: testAccProviderTLSConfig(server.URL, fmt.Sprintf(`ca_cert_pem = %q`, caCertPEM)) + testAccProviderTLSUserConfig,
+				Check:  resource.TestCheckResourceAttr("firezone_user.test", "email", "tls@example.com"),
+			},
+			{
+				Config: testAccProviderTLSConfig(server.URL, fmt.Sprintf(`ca_cert_file = %q`, caCertFile)) + testAccProviderTLSUserConfig,
+				Check:  resource.TestCheckResourceAttr("firezone_user.test", "email", "tls@example.com"),
+			},
+			{
+				Config: testAccProviderTLSConfig(server.URL, `insecure_skip_verify = true`) + testAccProviderTLSUserConfig,
+				Check:  resource.TestCheckResourceAttr("firezone_user.test", "email", "tls@example.com"),
+			},
+		},
+	})
+}
+
+func TestAccProvider_mutualTLS(t *testing.T) {
+	ca, caKey := testCertificateAuthority(t)
+	clientCert, clientKey := testClientCertificate(t, ca, caKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	server := httptest.NewUnstartedServer(testAccFirezone)
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+		MinVersion: tls.VersionTLS12,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caCertPEM := testCertificatePEM(server.Certificate().Raw)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// The server rejects connections without a client certificate
+			{
+				Config:      testAccProviderTLSConfig(server.URL, fmt.Sprintf(`ca_cert_pem = %q`, caCertPEM)) + testAccProviderTLSUserConfig,
+				ExpectError: regexp.MustCompile(`TLS connection to firezone endpoint failed`),
+			},
+			{
+				Config: testAccProviderTLSConfig(server.URL, fmt.Sprintf(`
+  ca_cert_pem = %q
+  client_cert = %q
+  client_key  = %q
+`, caCertPEM, clientCert, clientKey)) + testAccProviderTLSUserConfig,
+				Check: resource.TestCheckResourceAttr("firezone_user.test", "email", "tls@example.com"),
+			},
+			// The certificate and key can come from the configuration and
+			// the environment
+			{
+				PreConfig: func() {
+					t.Setenv("FIREZONE_CLIENT_KEY", clientKey)
+				},
+				Config: testAccProviderTLSConfig(server.URL, fmt.Sprintf(`
+  ca_cert_pem = %q
+  client_cert = %q
+`, caCertPEM, clientCert)) + testAccProviderTLSUserConfig,
+				Check: resource.TestCheckResourceAttr("firezone_user.test", "email", "tls@example.com"),
+			},
+			{
+				PreConfig: func() {
+					t.Setenv("FIREZONE_CLIENT_KEY", "")
+					t.Setenv("FIREZONE_CLIENT_CERT", clientCert)
+				},
+				Config: testAccProviderTLSConfig(server.URL, fmt.Sprintf(`
+  ca_cert_pem = %q
+  client_key  = %q
+`, caCertPEM, clientKey)) + testAccProviderTLSUserConfig,
+				Check: resource.TestCheckResourceAttr("firezone_user.test", "email", "tls@example.com"),
+			},
+		},
+	})
+}
+
+func TestAccProvider_invalidTLS(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderTLSConfig(testAccFirezoneURL, `ca_cert_pem = "not a certificate"`) + testAccProviderTLSDataSourceConfig,
+				ExpectError: regexp.MustCompile(`Invalid CA certificate`),
+			},
+			{
+				Config:      testAccProviderTLSConfig(testAccFirezoneURL, `ca_cert_file = "/nonexistent/ca.pem"`) + testAccProviderTLSDataSourceConfig,
+				ExpectError: regexp.MustCompile(`Unable to read CA certificate`),
+			},
+			{
+				Config:      testAccProviderTLSConfig(testAccFirezoneURL, `client_cert = "not a certificate"`) + testAccProviderTLSDataSourceConfig,
+				ExpectError: regexp.MustCompile(`Incomplete client certificate`),
+			},
+		},
+	})
+}
+
+const testAccProviderTLSUserConfig = `
+resource "firezone_user" "test" {
+  email = "tls@example.com"
+  role  = "unprivileged"
+}
+`
+
+const testAccProviderTLSDataSourceConfig = `
+data "firezone_user" "test" {
+  email = "nobody@example.com"
+}
+`
+
+func testAccProviderTLSConfig(endpoint string, settings string) string {
+	return fmt.Sprintf(`
+provider "firezone" {
+  api_key     = %[1]q
+  endpoint    = %[2]q
+  max_retries = 0
+  %[3]s
+}
+`, testAccApiKey, endpoint, settings)
+}
+
+func testCertificatePEM(der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func testCertificateAuthority(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ca, key
+}
+
+func testClientCertificate(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testCertificatePEM(der), string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultRetryWaitMin = 1 * time.Second
+	defaultRetryWaitMax = 30 * time.Second
+)
+
+// retryTransport retries failed Firezone API requests with exponential
+// backoff and jitter. Rate limited (429) requests are retried for every
+// method, network errors and 5xx responses only for idempotent methods so
+// a create is never sent twice.
+type retryTransport struct {
+	base http.RoundTripper
+
+	maxRetries int
+	waitMin    time.Duration
+	waitMax    time.Duration
+
+	// timeout limits each attempt, including reading the response body.
+	timeout time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, cancel, err := t.roundTrip(req)
+
+		if attempt >= t.maxRetries || !t.shouldRetry(req, resp, err) {
+			if resp != nil {
+				resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			} else {
+				cancel()
+			}
+			return resp, err
+		}
+
+		wait := t.backoff(attempt, resp)
+
+		fields := map[string]interface{}{
+			"method":  req.Method,
+			"url":     req.URL.String(),
+			"attempt": attempt + 1,
+			"wait":    wait.String(),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		} else {
+			fields["status"] = resp.StatusCode
+		}
+		tflog.Warn(req.Context(), "retrying Firezone API request", fields)
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		cancel()
+
+		if req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// roundTrip sends a single attempt of req. The returned function releases
+// the attempt's timeout once its response is no longer used.
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, context.CancelFunc, error) {
+	if t.timeout <= 0 {
+		resp, err := t.base.RoundTrip(req)
+		return resp, func() {}, err
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	return resp, cancel, err
+}
+
+// cancelBody releases an attempt's timeout when its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	// A body which cannot be replayed cannot be sent again.
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+
+	// Certificate and handshake failures do not go away by themselves.
+	if err != nil && isTLSError(err) {
+		return false
+	}
+
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if !isIdempotent(req.Method) {
+		return false
+	}
+
+	return err != nil || resp.StatusCode >= 500
+}
+
+// backoff returns how long to wait before retrying attempt. A Retry-After
+// header takes precedence, otherwise the wait doubles with every attempt.
+// Both are capped at waitMax.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if wait > t.waitMax {
+				return t.waitMax
+			}
+			return wait
+		}
+	}
+
+	// Compare before converting so large attempts cannot overflow, while a
+	// zero minimum keeps retrying without waiting.
+	wait := t.waitMax
+	if backoff := float64(t.waitMin) * math.Pow(2, float64(attempt)); backoff < float64(t.waitMax) {
+		wait = time.Duration(backoff)
+	}
+
+	// Randomise the wait down to half of it so concurrent requests spread
+	// out.
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// isIdempotent reports whether repeating a request with method is safe.
+// Firezone's PATCH endpoints set the given fields, so they are safe to
+// repeat as well.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
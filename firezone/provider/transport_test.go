@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRetryServer fails the first failures requests with status and counts
+// all requests it receives.
+func testRetryServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *int32) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func testRetryClient(maxRetries int) *http.Client {
+	return &http.Client{
+		Transport: &retryTransport{
+			base:       http.DefaultTransport,
+			maxRetries: maxRetries,
+			waitMin:    time.Millisecond,
+			waitMax:    10 * time.Millisecond,
+		},
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		failures   int32
+		status     int
+		maxRetries int
+		expected   int
+		requests   int32
+	}{
+		{"get retried on 502", http.MethodGet, 2, http.StatusBadGateway, 3, http.StatusOK, 3},
+		{"patch retried on 503", http.MethodPatch, 1, http.StatusServiceUnavailable, 3, http.StatusOK, 2},
+		{"post retried on 429", http.MethodPost, 1, http.StatusTooManyRequests, 3, http.StatusOK, 2},
+		{"post not retried on 500", http.MethodPost, 1, http.StatusInternalServerError, 3, http.StatusInternalServerError, 1},
+		{"client errors not retried", http.MethodGet, 1, http.StatusNotFound, 3, http.StatusNotFound, 1},
+		{"gives up after max retries", http.MethodDelete, 5, http.StatusBadGateway, 2, http.StatusBadGateway, 3},
+		{"retries disabled", http.MethodGet, 1, http.StatusBadGateway, 0, http.StatusBadGateway, 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server, requests := testRetryServer(t, c.failures, c.status, nil)
+
+			req, err := http.NewRequest(c.method, server.URL, strings.NewReader(`{"user":{}}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := testRetryClient(c.maxRetries).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != c.expected {
+				t.Errorf("expected status %d, got %d", c.expected, resp.StatusCode)
+			}
+			if *requests != c.requests {
+				t.Errorf("expected %d requests, got %d", c.requests, *requests)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_retryAfter(t *testing.T) {
+	server, requests := testRetryServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+
+	transport := &retryTransport{
+		base:       http.DefaultTransport,
+		maxRetries: 1,
+		waitMin:    time.Millisecond,
+		waitMax:    time.Minute,
+	}
+
+	start := time.Now()
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait for Retry-After, retried after %s", elapsed)
+	}
+	if *requests != 2 {
+		t.Errorf("expected 2 requests, got %d", *requests)
+	}
+}
+
+func TestRetryTransport_timeout(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			time.Sleep(time.Second)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	transport := &retryTransport{
+		base:       http.DefaultTransport,
+		maxRetries: 1,
+		waitMin:    time.Millisecond,
+		waitMax:    10 * time.Millisecond,
+		timeout:    100 * time.Millisecond,
+	}
+
+	start := time.Now()
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("expected the slow attempt to time out, took %s", elapsed)
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestRetryTransport_backoff(t *testing.T) {
+	transport := &retryTransport{
+		waitMin: 0,
+		waitMax: 30 * time.Second,
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		if wait := transport.backoff(attempt, nil); wait != 0 {
+			t.Errorf("expected no wait for attempt %d with a zero minimum, got %s", attempt, wait)
+		}
+	}
+
+	transport.waitMin = time.Second
+
+	for _, attempt := range []int{10, 100, 10000} {
+		if wait := transport.backoff(attempt, nil); wait < transport.waitMax/2 || wait > transport.waitMax {
+			t.Errorf("expected attempt %d to wait between %s and %s, got %s", attempt, transport.waitMax/2, transport.waitMax, wait)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if wait, ok := retryAfter("120"); !ok || wait != 2*time.Minute {
+		t.Errorf("expected 2m, got %s", wait)
+	}
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if wait, ok := retryAfter(date); !ok || wait < 59*time.Minute {
+		t.Errorf("expected about 1h, got %s", wait)
+	}
+
+	if _, ok := retryAfter("soon"); ok {
+		t.Error("expected invalid Retry-After to be ignored")
+	}
+}
@@ -43,7 +43,7 @@ func (d *UserDataSource) Schema(ctx context.Context, req datasource.SchemaReques
 
 		Attributes: map[string]schema.Attribute{
 			"disabled_at": schema.StringAttribute{
-				MarkdownDescription: "User disabled at",
+				MarkdownDescription: "Time the user was disabled in RFC3339 format, null when the user is enabled",
 				Computed:            true,
 			},
 			"role": schema.StringAttribute{
@@ -109,6 +109,7 @@ func (d *UserDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 	data.Id = types.StringValue(user.ID)
 	data.Email = types.StringValue(user.Email)
 	data.Role = types.StringValue(user.Role)
+	data.DisabledAt = rfc3339(user.DisabledAt)
 
 	// Write logs using the tflog package
 	// Documentation: https://terraform.io/plugin/log
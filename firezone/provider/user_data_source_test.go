@@ -13,9 +13,12 @@ func TestAccUserDataSource(t *testing.T) {
 		Steps: []resource.TestStep{
 			// Read testing
 			{
-				Config: testAccUserDataSourceConfig,
+				Config: providerConfig + testAccUserDataSourceConfig,
 				Check: resource.ComposeAggregateTestCheckFunc(
-					resource.TestCheckResourceAttr("data.firezone_user.test", "id", "example-id"),
+					resource.TestCheckResourceAttrPair("data.firezone_user.by_email", "id", "firezone_user.test", "id"),
+					resource.TestCheckResourceAttr("data.firezone_user.by_email", "role", "admin"),
+					resource.TestCheckResourceAttr("data.firezone_user.by_id", "email", "datasource@example.com"),
+					resource.TestCheckResourceAttr("data.firezone_user.by_id", "role", "admin"),
 				),
 			},
 		},
@@ -23,7 +26,16 @@ func TestAccUserDataSource(t *testing.T) {
 }
 
 const testAccUserDataSourceConfig = `
-data "firezone_user" "test" {
-  email = "one@example.com"
+resource "firezone_user" "test" {
+  email = "datasource@example.com"
+  role  = "admin"
+}
+
+data "firezone_user" "by_email" {
+  email = firezone_user.test.email
+}
+
+data "firezone_user" "by_id" {
+  id = firezone_user.test.id
 }
 `
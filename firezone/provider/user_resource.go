@@ -3,14 +3,18 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
-	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -22,6 +26,13 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &UserResource{}
 var _ resource.ResourceWithImportState = &UserResource{}
+var _ resource.ResourceWithValidateConfig = &UserResource{}
+
+// Length limits Firezone enforces for local passwords.
+const (
+	minPasswordLength = 12
+	maxPasswordLength = 64
+)
 
 func NewUserResource() resource.Resource {
 	return &UserResource{}
@@ -37,7 +48,13 @@ type UserResourceModel struct {
 	Id         types.String `tfsdk:"id"`
 	Email      types.String `tfsdk:"email"`
 	Role       types.String `tfsdk:"role"`
+	Disabled   types.Bool   `tfsdk:"disabled"`
 	DisabledAt types.String `tfsdk:"disabled_at"`
+	// The password is never returned by Firezone, so it is only ever
+	// taken from the configuration.
+	Password             types.String `tfsdk:"password"`
+	PasswordConfirmation types.String `tfsdk:"password_confirmation"`
+	PasswordVersion      types.String `tfsdk:"password_version"`
 }
 
 func (r *UserResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -50,11 +67,45 @@ func (r *UserResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 		MarkdownDescription: "User resource",
 
 		Attributes: map[string]schema.Attribute{
-			"disabled_at": schema.StringAttribute{
-				MarkdownDescription: "User disabled at",
+			"disabled": schema.BoolAttribute{
+				MarkdownDescription: "Whether the user is disabled. Disabled users cannot sign in and their devices cannot connect.",
 				Optional:            true,
 				Computed:            true,
-				Default:             stringdefault.StaticString(""),
+				Default:             booldefault.StaticBool(false),
+			},
+			"disabled_at": schema.StringAttribute{
+				MarkdownDescription: "Time the user was disabled in RFC3339 format, null when the user is enabled",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					disabledAtPlanModifier{},
+				},
+			},
+			"password": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Password for signing in with email and password, %d to %d characters. "+
+					"It is only sent to Firezone, never read back, so changes made outside of Terraform are not detected; "+
+					"change `password_version` to set it again. Removing it leaves the current password in place.",
+					minPasswordLength, maxPasswordLength),
+				Optional:  true,
+				Sensitive: true,
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(minPasswordLength, maxPasswordLength),
+					stringvalidator.AlsoRequires(path.MatchRoot("password_confirmation")),
+				},
+			},
+			"password_confirmation": schema.StringAttribute{
+				MarkdownDescription: "Confirmation of `password`, must be the same",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("password")),
+				},
+			},
+			"password_version": schema.StringAttribute{
+				MarkdownDescription: "Arbitrary value which sets `password` again whenever it changes, e.g. to rotate it",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("password")),
+				},
 			},
 			"role": schema.StringAttribute{
 				MarkdownDescription: "User role",
@@ -69,15 +120,14 @@ func (r *UserResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 				},
 			},
 			"email": schema.StringAttribute{
-				MarkdownDescription: "User email",
+				MarkdownDescription: "User email, compared case-insensitively",
 				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					caseInsensitive(),
+				},
 				Validators: []validator.String{
-					// These are example validators from terraform-plugin-framework-validators
 					stringvalidator.LengthBetween(1, 256),
-					stringvalidator.RegexMatches(
-						regexp.MustCompile(`^([0-9a-z]*@[0-9a-z]*\.[a-z]*)$`),
-						"must be a valid email address",
-					),
+					isEmail(),
 				},
 			},
 			"id": schema.StringAttribute{
@@ -121,23 +171,35 @@ func (r *UserResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	user, err := r.client.CreateUser(fz.User{
+	input := fz.User{
 		Email: data.Email.ValueString(),
 		Role:  data.Role.ValueString(),
-	})
+	}
+
+	var user *fz.User
+	var err error
+
+	if data.Password.IsNull() {
+		user, err = r.client.CreateUser(input)
+	} else {
+		user, err = createUserWithPassword(r.client, input, data.Password.ValueString(), data.PasswordConfirmation.ValueString())
+	}
 
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create user, got error: %s", err))
+		addUserError(&resp.Diagnostics, "create", data.Email.ValueString(), err)
 		return
 	}
 
-	// For the purposes of this example code, hardcoding a response value to
-	// save into the Terraform state.
+	if data.Disabled.ValueBool() {
+		user, err = setUserDisabled(r.client, user.ID, true)
+
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to disable user, got error: %s", err))
+			return
+		}
+	}
 
-	data.Id = types.StringValue(user.ID)
-	data.Email = types.StringValue(user.Email)
-	data.Role = types.StringValue(user.Role)
-	data.DisabledAt = types.StringValue(user.DisabledAt)
+	data.fromUser(user)
 
 	// Write logs using the tflog package
 	// Documentation: https://terraform.io/plugin/log
@@ -159,24 +221,30 @@ func (r *UserResource) Read(ctx context.Context, req resource.ReadRequest, resp
 
 	user, err := r.client.GetUser(data.Id.ValueString())
 
+	if isNotFound(err) {
+		// The user was deleted outside of Terraform, e.g. in the admin UI.
+		tflog.Warn(ctx, "user not found, removing from state", map[string]interface{}{"id": data.Id.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read user, got error: %s", err))
 		return
 	}
 
-	data.Id = types.StringValue(user.ID)
-	data.Email = types.StringValue(user.Email)
-	data.Role = types.StringValue(user.Role)
+	data.fromUser(user)
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *UserResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var data *UserResourceModel
+	var data, state *UserResourceModel
 
-	// Read Terraform plan data into the model
+	// Read Terraform plan and prior state data into the models
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 
 	if resp.Diagnostics.HasError() {
 		return
@@ -188,14 +256,34 @@ func (r *UserResource) Update(ctx context.Context, req resource.UpdateRequest, r
 	})
 
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update user, got error: %s", err))
+		addUserError(&resp.Diagnostics, "update", data.Email.ValueString(), err)
 		return
 	}
 
-	data.Id = types.StringValue(user.ID)
-	data.Email = types.StringValue(user.Email)
-	data.Role = types.StringValue(user.Role)
-	data.DisabledAt = types.StringValue(user.DisabledAt)
+	// The password is sent when it or its version changed.
+	if !data.Password.IsNull() && (!data.Password.Equal(state.Password) || !data.PasswordVersion.Equal(state.PasswordVersion)) {
+		user, err = setUserPassword(r.client, user.ID, data.Password.ValueString(), data.PasswordConfirmation.ValueString())
+
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set user password, got error: %s", err))
+			return
+		}
+	}
+
+	if disabled := data.Disabled.ValueBool(); disabled != (user.DisabledAt != "") {
+		user, err = setUserDisabled(r.client, user.ID, disabled)
+
+		if err != nil {
+			action := "enable"
+			if disabled {
+				action = "disable"
+			}
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to %s user, got error: %s", action, err))
+			return
+		}
+	}
+
+	data.fromUser(user)
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
@@ -213,15 +301,173 @@ func (r *UserResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 
 	err := r.client.DeleteUser(data.Id.ValueString())
 
-	// If applicable, this is a great opportunity to initialize any necessary
-	// provider client data and make a call using it.
-	// httpResp, err := r.client.Do(httpReq)
+	// A user which is already gone is as good as deleted.
+	if isNotFound(err) {
+		return
+	}
+
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete user, got error: %s", err))
 		return
 	}
 }
 
+func (r *UserResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data UserResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Neither value is included, they are secret.
+	if isKnown(data.Password) && isKnown(data.PasswordConfirmation) && data.Password.ValueString() != data.PasswordConfirmation.ValueString() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("password_confirmation"),
+			"Invalid Attribute Value",
+			"Attribute password_confirmation must be the same as password.",
+		)
+	}
+}
+
 func (r *UserResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	// Users are imported by id or by email.
+	id, ok := importUserID(r.client, &resp.Diagnostics, req.ID)
+
+	if !ok {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+}
+
+// fromUser copies a Firezone user into the model. The email is kept when
+// it only differs in case, Firezone may store it in lower case.
+func (m *UserResourceModel) fromUser(user *fz.User) {
+	m.Id = types.StringValue(user.ID)
+	if !strings.EqualFold(m.Email.ValueString(), user.Email) {
+		m.Email = types.StringValue(user.Email)
+	}
+	m.Role = types.StringValue(user.Role)
+	m.Disabled = types.BoolValue(user.DisabledAt != "")
+	m.DisabledAt = rfc3339(user.DisabledAt)
+}
+
+// addUserError adds the error of a failed user request. Firezone rejecting
+// the email is reported on the email attribute.
+func addUserError(diags *diag.Diagnostics, action string, email string, err error) {
+	if messages := fieldErrors(err)["email"]; len(messages) > 0 {
+		diags.AddAttributeError(
+			path.Root("email"),
+			"Invalid Email Address",
+			fmt.Sprintf("Firezone rejected the email address %q: %s.", email, strings.Join(messages, ", ")),
+		)
+		return
+	}
+
+	diags.AddError("Client Error", fmt.Sprintf("Unable to %s user, got error: %s", action, err))
+}
+
+// setUserDisabled disables or enables a user. The Firezone client does not
+// cover these endpoints.
+func setUserDisabled(client *fz.Client, id string, disabled bool) (*fz.User, error) {
+	action := "enable"
+	if disabled {
+		action = "disable"
+	}
+
+	var user fz.User
+
+	if err := apiRequest(client, http.MethodPost, fmt.Sprintf("/v0/users/%s/%s", id, action), nil, &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// createUserWithPassword creates a user with a password, which the Firezone
+// client cannot send.
+func createUserWithPassword(client *fz.Client, input fz.User, password string, confirmation string) (*fz.User, error) {
+	var user fz.User
+
+	body := map[string]interface{}{
+		"user": map[string]interface{}{
+			"email":                 input.Email,
+			"role":                  input.Role,
+			"password":              password,
+			"password_confirmation": confirmation,
+		},
+	}
+
+	if err := apiRequest(client, http.MethodPost, "/v0/users", body, &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// setUserPassword changes the password of a user, which the Firezone client
+// cannot send.
+func setUserPassword(client *fz.Client, id string, password string, confirmation string) (*fz.User, error) {
+	var user fz.User
+
+	body := map[string]interface{}{
+		"user": map[string]interface{}{
+			"password":              password,
+			"password_confirmation": confirmation,
+		},
+	}
+
+	if err := apiRequest(client, http.MethodPatch, "/v0/users/"+id, body, &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// rfc3339 converts a Firezone timestamp into RFC3339 format, mapping the
+// empty string, which the Firezone client decodes JSON null into, to a null
+// value. Timestamps which cannot be parsed are kept as they are.
+func rfc3339(value string) types.String {
+	if value == "" {
+		return types.StringNull()
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return types.StringValue(value)
+	}
+
+	return types.StringValue(t.UTC().Format(time.RFC3339))
+}
+
+// disabledAtPlanModifier keeps disabled_at from the state unless the user
+// is disabled or enabled by the plan.
+type disabledAtPlanModifier struct{}
+
+func (m disabledAtPlanModifier) Description(ctx context.Context) string {
+	return "Keeps the value from the state unless disabled changes."
+}
+
+func (m disabledAtPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m disabledAtPlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	// Nothing to keep on create or destroy.
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var planDisabled, stateDisabled types.Bool
+
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("disabled"), &planDisabled)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("disabled"), &stateDisabled)...)
+
+	if resp.Diagnostics.HasError() || planDisabled.IsUnknown() || !planDisabled.Equal(stateDisabled) {
+		return
+	}
+
+	resp.PlanValue = req.StateValue
 }
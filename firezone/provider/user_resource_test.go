@@ -2,23 +2,28 @@ package provider
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
 )
 
 func TestAccUserResource(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		PreCheck:                 func() { testAccPreCheck(t) },
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckUserResourceDestroy,
 		Steps: []resource.TestStep{
 			// Create and Read testing
 			{
-				Config: providerConfig + testAccUserResourceConfig("one@example.com"),
+				Config: providerConfig + testAccUserResourceConfig("one@example.com", "unprivileged"),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("firezone_user.test", "email", "one@example.com"),
 					resource.TestCheckResourceAttr("firezone_user.test", "role", "unprivileged"),
-					resource.TestCheckResourceAttr("firezone_user.test", "id", "example-id"),
+					resource.TestCheckResourceAttrSet("firezone_user.test", "id"),
+					resource.TestCheckResourceAttr("firezone_user.test", "disabled", "false"),
+					resource.TestCheckNoResourceAttr("firezone_user.test", "disabled_at"),
 				),
 			},
 			// ImportState testing
@@ -26,18 +31,25 @@ func TestAccUserResource(t *testing.T) {
 				ResourceName:      "firezone_user.test",
 				ImportState:       true,
 				ImportStateVerify: true,
-				// This is not normally necessary, but is here because this
-				// example code does not have an actual upstream service.
-				// Once the Read method is able to refresh information from
-				// the upstream service, this can be removed.
-				ImportStateVerifyIgnore: []string{"email", "defaulted"},
+			},
+			{
+				ResourceName:      "firezone_user.test",
+				ImportState:       true,
+				ImportStateId:     "one@example.com",
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:  "firezone_user.test",
+				ImportState:   true,
+				ImportStateId: "nobody@example.com",
+				ExpectError:   regexp.MustCompile(`No\s+user\s+matches\s+the\s+import\s+identifier`),
 			},
 			// Update and Read testing
 			{
-				Config: testAccUserResourceConfig("two@example.com"),
+				Config: providerConfig + testAccUserResourceConfig("two@example.com", "admin"),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("firezone_user.test", "email", "two@example.com"),
-					resource.TestCheckResourceAttr("firezone_user.test", "role", "unprivileged"),
+					resource.TestCheckResourceAttr("firezone_user.test", "role", "admin"),
 				),
 			},
 			// Delete testing automatically occurs in TestCase
@@ -45,11 +57,316 @@ func TestAccUserResource(t *testing.T) {
 	})
 }
 
-func testAccUserResourceConfig(email string) string {
+func TestAccUserResource_disabled(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckUserResourceDestroy,
+		Steps: []resource.TestStep{
+			// Create disabled
+			{
+				Config: providerConfig + testAccUserResourceDisabledConfig(true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_user.test", "disabled", "true"),
+					resource.TestMatchResourceAttr("firezone_user.test", "disabled_at", regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`)),
+					testAccCheckUserDisabled("firezone_user.test", true),
+				),
+			},
+			{
+				ResourceName:      "firezone_user.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			// Enable
+			{
+				Config: providerConfig + testAccUserResourceDisabledConfig(false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_user.test", "disabled", "false"),
+					resource.TestCheckNoResourceAttr("firezone_user.test", "disabled_at"),
+					testAccCheckUserDisabled("firezone_user.test", false),
+				),
+			},
+			// Disabled outside of Terraform shows up as drift
+			{
+				PreConfig:          func() { testAccFirezone.disableUser("disabled@example.com") },
+				Config:             providerConfig + testAccUserResourceDisabledConfig(false),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			// and is reverted by the next apply.
+			{
+				Config: providerConfig + testAccUserResourceDisabledConfig(false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_user.test", "disabled", "false"),
+					testAccCheckUserDisabled("firezone_user.test", false),
+				),
+			},
+			// Disable
+			{
+				Config: providerConfig + testAccUserResourceDisabledConfig(true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_user.test", "disabled", "true"),
+					resource.TestCheckResourceAttrSet("firezone_user.test", "disabled_at"),
+					testAccCheckUserDisabled("firezone_user.test", true),
+				),
+			},
+		},
+	})
+}
+
+func TestAccUserResource_password(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckUserResourceDestroy,
+		Steps: []resource.TestStep{
+			// Create with a password
+			{
+				Config: providerConfig + testAccUserResourcePasswordConfig("correct-horse-1", "1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_user.test", "password", "correct-horse-1"),
+					testAccCheckUserPassword("firezone_user.test", "correct-horse-1"),
+				),
+			},
+			// The password is never read back.
+			{
+				ResourceName:            "firezone_user.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"password", "password_confirmation", "password_version"},
+			},
+			// Change the password
+			{
+				Config: providerConfig + testAccUserResourcePasswordConfig("battery-staple-2", "1"),
+				Check:  testAccCheckUserPassword("firezone_user.test", "battery-staple-2"),
+			},
+			// A password changed outside of Terraform is not detected,
+			{
+				PreConfig: func() { testAccFirezone.changeUserPassword("password@example.com", "changed-in-the-ui") },
+				Config:    providerConfig + testAccUserResourcePasswordConfig("battery-staple-2", "1"),
+				Check:     testAccCheckUserPassword("firezone_user.test", "changed-in-the-ui"),
+			},
+			// but a new password_version sets it again.
+			{
+				Config: providerConfig + testAccUserResourcePasswordConfig("battery-staple-2", "2"),
+				Check:  testAccCheckUserPassword("firezone_user.test", "battery-staple-2"),
+			},
+		},
+	})
+}
+
+func TestAccUserResource_invalidPassword(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      providerConfig + testAccUserResourcePasswordConfig("too-short", "1"),
+				ExpectError: regexp.MustCompile(`string\s+length\s+must\s+be\s+between\s+12\s+and\s+64`),
+			},
+			{
+				Config: providerConfig + `
+resource "firezone_user" "test" {
+  email                 = "password@example.com"
+  role                  = "admin"
+  password              = "correct-horse-1"
+  password_confirmation = "correct-horse-2"
+}
+`,
+				ExpectError: regexp.MustCompile(`password_confirmation\s+must\s+be\s+the\s+same\s+as\s+password`),
+			},
+			{
+				Config: providerConfig + `
+resource "firezone_user" "test" {
+  email    = "password@example.com"
+  role     = "admin"
+  password = "correct-horse-1"
+}
+`,
+				ExpectError: regexp.MustCompile(`Attribute\s+"password_confirmation"\s+must\s+be\s+specified`),
+			},
+		},
+	})
+}
+
+func TestAccUserResource_email(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckUserResourceDestroy,
+		Steps: []resource.TestStep{
+			// Firezone stores the email in lower case, the configured case is kept.
+			{
+				Config: providerConfig + testAccUserResourceConfig("Jane.Doe+VPN@Corp.Example.co.uk", "unprivileged"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_user.test", "email", "Jane.Doe+VPN@Corp.Example.co.uk"),
+					testAccCheckUserEmail("firezone_user.test", "jane.doe+vpn@corp.example.co.uk"),
+				),
+			},
+			// Changing only the case is not a diff.
+			{
+				Config:   providerConfig + testAccUserResourceConfig("jane.doe+vpn@corp.example.co.uk", "unprivileged"),
+				PlanOnly: true,
+			},
+			{
+				Config: providerConfig + testAccUserResourceConfig("jane-doe@corp.example.co.uk", "unprivileged"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("firezone_user.test", "email", "jane-doe@corp.example.co.uk"),
+					testAccCheckUserEmail("firezone_user.test", "jane-doe@corp.example.co.uk"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccUserResource_invalidEmail(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      providerConfig + testAccUserResourceConfig("Jane Doe <jane@example.com>", "unprivileged"),
+				ExpectError: regexp.MustCompile(`must\s+be\s+a\s+valid\s+email\s+address`),
+			},
+			// Valid by RFC 5322, but not for Firezone.
+			{
+				Config:      providerConfig + testAccUserResourceConfig(`"jane doe"@example.com`, "unprivileged"),
+				ExpectError: regexp.MustCompile(`Firezone\s+rejected\s+the\s+email\s+address(.|\s)*has\s+invalid\s+format`),
+			},
+		},
+	})
+}
+
+func TestAccUserResource_duplicateEmail(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "firezone_user" "first" {
+  email = "duplicate@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_user" "second" {
+  email = firezone_user.first.email
+  role  = "unprivileged"
+}
+`,
+				ExpectError: regexp.MustCompile(`already\s+been\s+taken`),
+			},
+		},
+	})
+}
+
+func TestAccUserResource_disappears(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckUserResourceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:             providerConfig + testAccUserResourceConfig("disappears@example.com", "unprivileged"),
+				Check:              testAccCheckDisappears("firezone_user.test", testAccFirezone.deleteUser),
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func testAccCheckUserResourceDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "firezone_user" {
+			continue
+		}
+		if testAccFirezone.user(rs.Primary.ID) != nil {
+			return fmt.Errorf("user %s still exists", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+// testAccCheckUserDisabled checks whether Firezone has the user of the
+// resource disabled.
+func testAccCheckUserDisabled(name string, disabled bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+		u := testAccFirezone.user(rs.Primary.ID)
+		if u == nil {
+			return fmt.Errorf("user %s does not exist", rs.Primary.ID)
+		}
+		if (u.DisabledAt != nil) != disabled {
+			return fmt.Errorf("user %s disabled is %t, want %t", rs.Primary.ID, u.DisabledAt != nil, disabled)
+		}
+		return nil
+	}
+}
+
+// testAccCheckUserEmail checks the email Firezone has for the user of the
+// resource.
+func testAccCheckUserEmail(name string, email string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+		u := testAccFirezone.user(rs.Primary.ID)
+		if u == nil {
+			return fmt.Errorf("user %s does not exist", rs.Primary.ID)
+		}
+		if u.Email != email {
+			return fmt.Errorf("user %s email is %q, want %q", rs.Primary.ID, u.Email, email)
+		}
+		return nil
+	}
+}
+
+// testAccCheckUserPassword checks the password Firezone has for the user of
+// the resource.
+func testAccCheckUserPassword(name string, password string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+		if got := testAccFirezone.userPassword(rs.Primary.ID); got != password {
+			return fmt.Errorf("user %s password is %q, want %q", rs.Primary.ID, got, password)
+		}
+		return nil
+	}
+}
+
+func testAccUserResourcePasswordConfig(password string, version string) string {
+	return fmt.Sprintf(`
+resource "firezone_user" "test" {
+  email                 = "password@example.com"
+  role                  = "admin"
+  password              = %[1]q
+  password_confirmation = %[1]q
+  password_version      = %[2]q
+}
+`, password, version)
+}
+
+func testAccUserResourceDisabledConfig(disabled bool) string {
+	return fmt.Sprintf(`
+resource "firezone_user" "test" {
+  email    = "disabled@example.com"
+  role     = "unprivileged"
+  disabled = %[1]t
+}
+`, disabled)
+}
+
+func testAccUserResourceConfig(email string, role string) string {
 	return fmt.Sprintf(`
 resource "firezone_user" "test" {
   email = %[1]q
-	role = %[2]q
+  role  = %[2]q
 }
-`, email, "unprivileged")
+`, email, role)
 }
@@ -0,0 +1,225 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	tfpath "github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	fz "github.com/jindrichskupa/firezone-client-go/client"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &UsersDataSource{}
+
+func NewUsersDataSource() datasource.DataSource {
+	return &UsersDataSource{}
+}
+
+// UsersDataSource defines the data source implementation.
+type UsersDataSource struct {
+	client *fz.Client
+}
+
+// UsersDataSourceModel describes the data source data model.
+type UsersDataSourceModel struct {
+	Id        types.String         `tfsdk:"id"`
+	Role      types.String         `tfsdk:"role"`
+	Disabled  types.Bool           `tfsdk:"disabled"`
+	EmailGlob types.String         `tfsdk:"email_glob"`
+	Users     []UserDataSourceUser `tfsdk:"users"`
+}
+
+// UserDataSourceUser describes a user returned by a data source.
+type UserDataSourceUser struct {
+	Id                 types.String `tfsdk:"id"`
+	Email              types.String `tfsdk:"email"`
+	Role               types.String `tfsdk:"role"`
+	DisabledAt         types.String `tfsdk:"disabled_at"`
+	LastSignedInAt     types.String `tfsdk:"last_signed_in_at"`
+	LastSignedInMethod types.String `tfsdk:"last_signed_in_method"`
+	InsertedAt         types.String `tfsdk:"inserted_at"`
+}
+
+func (d *UsersDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_users"
+}
+
+func (d *UsersDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Users data source. Lists all users matching every given filter.",
+
+		Attributes: map[string]schema.Attribute{
+			"role": schema.StringAttribute{
+				MarkdownDescription: "Only list users with this role, either admin or unprivileged",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(
+						regexp.MustCompile(`^(admin|unprivileged)$`),
+						"must be either admin or unprivileged",
+					),
+				},
+			},
+			"disabled": schema.BoolAttribute{
+				MarkdownDescription: "Only list disabled users when true, or enabled users when false",
+				Optional:            true,
+			},
+			"email_glob": schema.StringAttribute{
+				MarkdownDescription: "Only list users with an email matching this case-insensitive glob pattern, e.g. `*@example.com`",
+				Optional:            true,
+				Validators: []validator.String{
+					isGlob(),
+				},
+			},
+			"users": schema.ListNestedAttribute{
+				MarkdownDescription: "Matching users, sorted by email",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "User identifier",
+							Computed:            true,
+						},
+						"email": schema.StringAttribute{
+							MarkdownDescription: "User email",
+							Computed:            true,
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "User role",
+							Computed:            true,
+						},
+						"disabled_at": schema.StringAttribute{
+							MarkdownDescription: "Time the user was disabled in RFC3339 format, null when the user is enabled",
+							Computed:            true,
+						},
+						"last_signed_in_at": schema.StringAttribute{
+							MarkdownDescription: "Time the user last signed in, null when the user never signed in",
+							Computed:            true,
+						},
+						"last_signed_in_method": schema.StringAttribute{
+							MarkdownDescription: "Method the user last signed in with, null when the user never signed in",
+							Computed:            true,
+						},
+						"inserted_at": schema.StringAttribute{
+							MarkdownDescription: "User creation time",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"id": schema.StringAttribute{
+				MarkdownDescription: "Data source identifier",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *UsersDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fz.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *UsersDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data UsersDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	emailGlob := strings.ToLower(data.EmailGlob.ValueString())
+
+	if !data.EmailGlob.IsNull() {
+		if _, err := path.Match(emailGlob, ""); err != nil {
+			resp.Diagnostics.AddAttributeError(tfpath.Root("email_glob"), "Invalid Attribute Value", err.Error())
+			return
+		}
+	}
+
+	users, err := d.client.GetAllUsers()
+
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read users, got error: %s", err))
+		return
+	}
+
+	data.Users = []UserDataSourceUser{}
+
+	for _, user := range *users {
+		if !data.Role.IsNull() && user.Role != data.Role.ValueString() {
+			continue
+		}
+
+		if !data.Disabled.IsNull() && (user.DisabledAt != "") != data.Disabled.ValueBool() {
+			continue
+		}
+
+		if !data.EmailGlob.IsNull() {
+			// Emails are compared case-insensitively, like Firezone does.
+			if matched, _ := path.Match(emailGlob, strings.ToLower(user.Email)); !matched {
+				continue
+			}
+		}
+
+		data.Users = append(data.Users, UserDataSourceUser{
+			Id:                 types.StringValue(user.ID),
+			Email:              types.StringValue(user.Email),
+			Role:               types.StringValue(user.Role),
+			DisabledAt:         rfc3339(user.DisabledAt),
+			LastSignedInAt:     optionalString(user.LastSignedInAt),
+			LastSignedInMethod: optionalString(user.LastSignedInMethod),
+			InsertedAt:         types.StringValue(user.InsertedAt),
+		})
+	}
+
+	sort.SliceStable(data.Users, func(i, j int) bool {
+		return data.Users[i].Email.ValueString() < data.Users[j].Email.ValueString()
+	})
+
+	data.Id = types.StringValue("users")
+
+	// Write logs using the tflog package
+	// Documentation: https://terraform.io/plugin/log
+	tflog.Trace(ctx, "read a data source")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// optionalString maps the empty string, which the Firezone client decodes
+// JSON null into, to a null value.
+func optionalString(value string) types.String {
+	if value == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(value)
+}
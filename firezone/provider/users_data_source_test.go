@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccUsersDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: providerConfig + testAccUsersDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.firezone_users.all", "id", "users"),
+					resource.TestCheckResourceAttr("data.firezone_users.all", "users.#", "3"),
+					resource.TestCheckResourceAttrPair("data.firezone_users.all", "users.0.id", "firezone_user.carol", "id"),
+					resource.TestCheckResourceAttr("data.firezone_users.all", "users.0.email", "carol@usertest.com"),
+					resource.TestCheckResourceAttr("data.firezone_users.all", "users.0.role", "admin"),
+					resource.TestCheckResourceAttrSet("data.firezone_users.all", "users.0.inserted_at"),
+					resource.TestCheckNoResourceAttr("data.firezone_users.all", "users.0.disabled_at"),
+					resource.TestCheckNoResourceAttr("data.firezone_users.all", "users.0.last_signed_in_at"),
+					resource.TestCheckNoResourceAttr("data.firezone_users.all", "users.0.last_signed_in_method"),
+					resource.TestCheckResourceAttrPair("data.firezone_users.all", "users.1.id", "firezone_user.dave", "id"),
+					resource.TestCheckResourceAttrPair("data.firezone_users.all", "users.2.id", "firezone_user.erin", "id"),
+
+					resource.TestCheckResourceAttr("data.firezone_users.admins", "users.#", "1"),
+					resource.TestCheckResourceAttrPair("data.firezone_users.admins", "users.0.id", "firezone_user.carol", "id"),
+
+					resource.TestCheckResourceAttrPair("data.firezone_users.all", "users.2.disabled_at", "firezone_user.erin", "disabled_at"),
+
+					resource.TestCheckResourceAttr("data.firezone_users.disabled", "users.#", "1"),
+					resource.TestCheckResourceAttrPair("data.firezone_users.disabled", "users.0.id", "firezone_user.erin", "id"),
+					resource.TestCheckResourceAttr("data.firezone_users.enabled", "users.#", "1"),
+					resource.TestCheckResourceAttrPair("data.firezone_users.enabled", "users.0.id", "firezone_user.dave", "id"),
+				),
+			},
+			// User signed in outside of Terraform
+			{
+				PreConfig: func() { testAccFirezone.signInUser("dave@usertest.com", "password") },
+				Config:    providerConfig + testAccUsersDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.firezone_users.all", "users.1.last_signed_in_at"),
+					resource.TestCheckResourceAttr("data.firezone_users.all", "users.1.last_signed_in_method", "password"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccUsersDataSource_invalidFilters(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+data "firezone_users" "test" {
+  email_glob = "[*@example.com"
+}
+`,
+				ExpectError: regexp.MustCompile(`must\s+be\s+a\s+valid\s+glob\s+pattern`),
+			},
+			{
+				Config: providerConfig + `
+data "firezone_users" "test" {
+  role = "owner"
+}
+`,
+				ExpectError: regexp.MustCompile(`must\s+be\s+either\s+admin\s+or\s+unprivileged`),
+			},
+		},
+	})
+}
+
+const testAccUsersDataSourceConfig = `
+resource "firezone_user" "carol" {
+  email = "carol@usertest.com"
+  role  = "admin"
+}
+
+resource "firezone_user" "dave" {
+  email = "dave@usertest.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_user" "erin" {
+  email    = "erin@usertest.com"
+  role     = "unprivileged"
+  disabled = true
+}
+
+data "firezone_users" "all" {
+  email_glob = "*@USERTEST.com"
+  depends_on = [firezone_user.carol, firezone_user.dave, firezone_user.erin]
+}
+
+data "firezone_users" "admins" {
+  email_glob = "*@usertest.com"
+  role       = "admin"
+  depends_on = [firezone_user.carol, firezone_user.dave, firezone_user.erin]
+}
+
+data "firezone_users" "disabled" {
+  email_glob = "*@usertest.com"
+  disabled   = true
+  depends_on = [firezone_user.carol, firezone_user.dave, firezone_user.erin]
+}
+
+data "firezone_users" "enabled" {
+  email_glob = "*@usertest.com"
+  role       = "unprivileged"
+  disabled   = false
+  depends_on = [firezone_user.carol, firezone_user.dave, firezone_user.erin]
+}
+`
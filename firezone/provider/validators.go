@@ -0,0 +1,398 @@
+package provider
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/netip"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+var _ validator.String = cidrValidator{}
+var _ validator.String = ipAddressValidator{}
+var _ validator.String = xmlValidator{}
+var _ validator.String = regexValidator{}
+var _ validator.String = globValidator{}
+var _ validator.String = emailValidator{}
+var _ validator.String = portRangeValidator{}
+var _ validator.String = ruleDestinationValidator{}
+
+// cidrValidator validates that a string is an IPv4 or IPv6 network in CIDR
+// notation.
+type cidrValidator struct{}
+
+func (v cidrValidator) Description(ctx context.Context) string {
+	return "must be an IPv4 or IPv6 network in CIDR notation"
+}
+
+func (v cidrValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v cidrValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+
+	if _, err := netip.ParsePrefix(value); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Attribute Value",
+			fmt.Sprintf("Attribute %s %s, got: %s", req.Path, v.Description(ctx), value),
+		)
+	}
+}
+
+// isCIDR returns a validator which ensures the value is a CIDR network.
+func isCIDR() validator.String {
+	return cidrValidator{}
+}
+
+// ipAddressValidator validates that a string is an IPv4 or IPv6 address.
+type ipAddressValidator struct{}
+
+func (v ipAddressValidator) Description(ctx context.Context) string {
+	return "must be an IPv4 or IPv6 address"
+}
+
+func (v ipAddressValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v ipAddressValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+
+	if _, err := netip.ParseAddr(value); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Attribute Value",
+			fmt.Sprintf("Attribute %s %s, got: %s", req.Path, v.Description(ctx), value),
+		)
+	}
+}
+
+// isIPAddress returns a validator which ensures the value is an IP address.
+func isIPAddress() validator.String {
+	return ipAddressValidator{}
+}
+
+// regexValidator validates that a string is a valid regular expression.
+type regexValidator struct{}
+
+func (v regexValidator) Description(ctx context.Context) string {
+	return "must be a valid regular expression"
+}
+
+func (v regexValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v regexValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := regexp.Compile(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Attribute Value",
+			fmt.Sprintf("Attribute %s %s, got error: %s", req.Path, v.Description(ctx), err),
+		)
+	}
+}
+
+// isRegex returns a validator which ensures the value is a regular
+// expression.
+func isRegex() validator.String {
+	return regexValidator{}
+}
+
+// globValidator validates that a string is a valid glob pattern.
+type globValidator struct{}
+
+func (v globValidator) Description(ctx context.Context) string {
+	return "must be a valid glob pattern"
+}
+
+func (v globValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v globValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+
+	if _, err := path.Match(value, ""); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Attribute Value",
+			fmt.Sprintf("Attribute %s %s, got: %s", req.Path, v.Description(ctx), value),
+		)
+	}
+}
+
+// isGlob returns a validator which ensures the value is a glob pattern.
+func isGlob() validator.String {
+	return globValidator{}
+}
+
+// emailValidator validates that a string is an email address.
+type emailValidator struct{}
+
+func (v emailValidator) Description(ctx context.Context) string {
+	return "must be a valid email address"
+}
+
+func (v emailValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v emailValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+
+	if err := checkEmail(value); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Attribute Value",
+			fmt.Sprintf("Attribute %s %s, got: %s (%s)", req.Path, v.Description(ctx), value, err),
+		)
+	}
+}
+
+// isEmail returns a validator which ensures the value is an email address.
+func isEmail() validator.String {
+	return emailValidator{}
+}
+
+// checkEmail returns an error unless email is a bare RFC 5322 address,
+// without a display name or angle brackets.
+func checkEmail(email string) error {
+	if strings.TrimSpace(email) != email {
+		return errors.New("leading or trailing whitespace")
+	}
+
+	// Within angle brackets only an address is accepted.
+	_, err := mail.ParseAddress("<" + email + ">")
+
+	return err
+}
+
+// ruleDestinationValidator validates that a string is an IPv4 or IPv6
+// network in CIDR notation or a single address.
+type ruleDestinationValidator struct{}
+
+func (v ruleDestinationValidator) Description(ctx context.Context) string {
+	return "must be an IPv4 or IPv6 network in CIDR notation or a single address"
+}
+
+func (v ruleDestinationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v ruleDestinationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+
+	if _, ok := parseRuleDestination(value); !ok {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Attribute Value",
+			fmt.Sprintf("Attribute %s %s, got: %s", req.Path, v.Description(ctx), value),
+		)
+	}
+}
+
+// isRuleDestination returns a validator which ensures the value is a
+// network or an address.
+func isRuleDestination() validator.String {
+	return ruleDestinationValidator{}
+}
+
+// portRangeValidator validates that a string is a single port or a range
+// of ports.
+type portRangeValidator struct{}
+
+func (v portRangeValidator) Description(ctx context.Context) string {
+	return "must be a port or a port range like 80-443, with ports from 1 to 65535"
+}
+
+func (v portRangeValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v portRangeValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+
+	if _, _, err := parsePortRange(value); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Attribute Value",
+			fmt.Sprintf("Attribute %s %s, got: %s (%s)", req.Path, v.Description(ctx), value, err),
+		)
+	}
+}
+
+// isPortRange returns a validator which ensures the value is a port range.
+func isPortRange() validator.String {
+	return portRangeValidator{}
+}
+
+// portRangeRegexp matches "N", "N-M" and "N - M".
+var portRangeRegexp = regexp.MustCompile(`^\s*(\d+)\s*(?:-\s*(\d+)\s*)?$`)
+
+// parsePortRange returns the first and last port of a port range, which is
+// a single port or two ports separated by a dash.
+func parsePortRange(portRange string) (int, int, error) {
+	m := portRangeRegexp.FindStringSubmatch(portRange)
+
+	if m == nil {
+		return 0, 0, errors.New("expected a port or two ports separated by a dash")
+	}
+
+	start, err := parsePort(m[1])
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end := start
+
+	if m[2] != "" {
+		if end, err = parsePort(m[2]); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if start > end {
+		return 0, 0, fmt.Errorf("first port %d is greater than last port %d", start, end)
+	}
+
+	return start, end, nil
+}
+
+// parsePort parses a port number between 1 and 65535.
+func parsePort(port string) (int, error) {
+	n, err := strconv.Atoi(port)
+
+	if err != nil || n < 1 || n > 65535 {
+		return 0, fmt.Errorf("port %s is not between 1 and 65535", port)
+	}
+
+	return n, nil
+}
+
+// canonicalPortRange returns a port range the way Firezone returns it,
+// e.g. "80 - 443" for "80-443" and "80" for "80-80". Invalid port ranges
+// are returned as they are.
+func canonicalPortRange(portRange string) string {
+	start, end, err := parsePortRange(portRange)
+
+	if err != nil {
+		return portRange
+	}
+
+	if start == end {
+		return strconv.Itoa(start)
+	}
+
+	return fmt.Sprintf("%d - %d", start, end)
+}
+
+// xmlValidator validates that a string is a well-formed XML document.
+type xmlValidator struct{}
+
+func (v xmlValidator) Description(ctx context.Context) string {
+	return "must be a well-formed XML document"
+}
+
+func (v xmlValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v xmlValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	// The document is not included in the diagnostic, metadata documents
+	// are too long to be helpful there.
+	if err := checkXML(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Attribute Value",
+			fmt.Sprintf("Attribute %s %s, got error: %s", req.Path, v.Description(ctx), err),
+		)
+	}
+}
+
+// isXML returns a validator which ensures the value is well-formed XML.
+func isXML() validator.String {
+	return xmlValidator{}
+}
+
+// checkXML returns an error unless document is well-formed XML with a
+// single root element.
+func checkXML(document string) error {
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	depth, roots := 0, 0
+
+	for {
+		token, err := decoder.Token()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(strings.TrimSpace(string(t))) > 0 {
+				return errors.New("text outside of the root element")
+			}
+		}
+	}
+
+	if roots != 1 {
+		return fmt.Errorf("expected a single root element, got %d", roots)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package provider
+
+import "testing"
+
+func TestCheckEmail(t *testing.T) {
+	valid := []string{
+		"jane@example.com",
+		"jane.doe@corp.example.co.uk",
+		"Jane.Doe@Corp.Example.com",
+		"jane+vpn@example.com",
+		"jane-doe@my-corp.example.com",
+		"o'brien@example.com",
+		`"jane doe"@example.com`,
+	}
+
+	for _, email := range valid {
+		if err := checkEmail(email); err != nil {
+			t.Errorf("%q: unexpected error: %s", email, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"jane",
+		"jane@",
+		"@example.com",
+		"jane@@example.com",
+		"jane doe@example.com",
+		"jane..doe@example.com",
+		"Jane Doe <jane@example.com>",
+		"<jane@example.com>",
+		" jane@example.com",
+	}
+
+	for _, email := range invalid {
+		if err := checkEmail(email); err == nil {
+			t.Errorf("%q: expected an error", email)
+		}
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	valid := map[string]string{
+		"80":          "80",
+		"1":           "1",
+		"65535":       "65535",
+		"80-443":      "80 - 443",
+		"80 - 443":    "80 - 443",
+		" 80 -443 ":   "80 - 443",
+		"443-443":     "443",
+		"1 - 65535":   "1 - 65535",
+		"0080 - 0443": "80 - 443",
+	}
+
+	for portRange, canonical := range valid {
+		if _, _, err := parsePortRange(portRange); err != nil {
+			t.Errorf("%q: unexpected error: %s", portRange, err)
+		}
+		if got := canonicalPortRange(portRange); got != canonical {
+			t.Errorf("%q: expected %q, got %q", portRange, canonical, got)
+		}
+	}
+
+	invalid := []string{
+		"",
+		" ",
+		"0",
+		"65536",
+		"0-80",
+		"80-65536",
+		"900 - 80",
+		"80-",
+		"-80",
+		"80 443",
+		"80,443",
+		"http",
+		"99999999999999999999",
+	}
+
+	for _, portRange := range invalid {
+		if _, _, err := parsePortRange(portRange); err == nil {
+			t.Errorf("%q: expected an error", portRange)
+		}
+		if got := canonicalPortRange(portRange); got != portRange {
+			t.Errorf("%q: expected it unchanged, got %q", portRange, got)
+		}
+	}
+}
+
+func TestCheckXML(t *testing.T) {
+	valid := []string{
+		`<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata"/>`,
+		"<?xml version=\"1.0\"?>\n<md:EntityDescriptor xmlns:md=\"urn:oasis:names:tc:SAML:2.0:metadata\">\n  <md:IDPSSODescriptor/>\n</md:EntityDescriptor>\n",
+	}
+
+	for _, document := range valid {
+		if err := checkXML(document); err != nil {
+			t.Errorf("%q: unexpected error: %s", document, err)
+		}
+	}
+
+	invalid := []string{
+		``,
+		`not xml`,
+		`<a><b></a>`,
+		`<a>`,
+		`<a/><b/>`,
+		`<a/>text`,
+	}
+
+	for _, document := range invalid {
+		if err := checkXML(document); err == nil {
+			t.Errorf("%q: expected an error", document)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/crypto/curve25519"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &WireGuardKeypairResource{}
+
+func NewWireGuardKeypairResource() resource.Resource {
+	return &WireGuardKeypairResource{}
+}
+
+// WireGuardKeypairResource defines the resource implementation. Keys are
+// generated locally and never sent to the Firezone API.
+type WireGuardKeypairResource struct{}
+
+// WireGuardKeypairResourceModel describes the resource data model.
+type WireGuardKeypairResourceModel struct {
+	Id         types.String `tfsdk:"id"`
+	PrivateKey types.String `tfsdk:"private_key"`
+	PublicKey  types.String `tfsdk:"public_key"`
+	Keepers    types.Map    `tfsdk:"keepers"`
+}
+
+func (r *WireGuardKeypairResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_wireguard_keypair"
+}
+
+func (r *WireGuardKeypairResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "WireGuard keypair resource. The Curve25519 keypair is generated locally by the provider, " +
+			"no Firezone API call is made. The private key is stored in the Terraform state.",
+
+		Attributes: map[string]schema.Attribute{
+			"keepers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary map of values that, when changed, will trigger generation of a new keypair",
+				ElementType:         types.StringType,
+				Optional:            true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"private_key": schema.StringAttribute{
+				MarkdownDescription: "Base64 encoded WireGuard private key",
+				Computed:            true,
+				Sensitive:           true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"public_key": schema.StringAttribute{
+				MarkdownDescription: "Base64 encoded WireGuard public key",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Keypair identifier, same as the public key",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *WireGuardKeypairResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *WireGuardKeypairResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	privateKey, publicKey, err := generateWireGuardKeypair()
+
+	if err != nil {
+		resp.Diagnostics.AddError("Key Generation Error", fmt.Sprintf("Unable to generate WireGuard keypair, got error: %s", err))
+		return
+	}
+
+	data.Id = types.StringValue(publicKey)
+	data.PrivateKey = types.StringValue(privateKey)
+	data.PublicKey = types.StringValue(publicKey)
+
+	tflog.Trace(ctx, "created a resource")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *WireGuardKeypairResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// The keypair only exists in the Terraform state, there is nothing to refresh.
+}
+
+func (r *WireGuardKeypairResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *WireGuardKeypairResourceModel
+
+	// Every configurable attribute requires replacement, so the plan only
+	// carries over the existing keys.
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *WireGuardKeypairResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// The keypair only exists in the Terraform state, which the framework
+	// removes after Delete returns.
+}
+
+// generateWireGuardKeypair returns a new base64 encoded private and public
+// key, the same as `wg genkey | tee private | wg pubkey`.
+func generateWireGuardKeypair() (string, string, error) {
+	privateKey := make([]byte, curve25519.ScalarSize)
+
+	if _, err := rand.Read(privateKey); err != nil {
+		return "", "", err
+	}
+
+	// Clamp the scalar as described in RFC 7748, like wg genkey does.
+	privateKey[0] &= 248
+	privateKey[31] = (privateKey[31] & 127) | 64
+
+	publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(privateKey), base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+// wireGuardPublicKey derives the base64 encoded public key of a base64
+// encoded private key.
+func wireGuardPublicKey(privateKey string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(privateKey)
+
+	if err != nil {
+		return "", fmt.Errorf("private key is not valid base64: %w", err)
+	}
+
+	if len(key) != curve25519.ScalarSize {
+		return "", fmt.Errorf("private key must be %d bytes, got %d", curve25519.ScalarSize, len(key))
+	}
+
+	publicKey, err := curve25519.X25519(key, curve25519.Basepoint)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(publicKey), nil
+}
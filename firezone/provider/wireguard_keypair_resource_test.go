@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccWireGuardKeypairResource(t *testing.T) {
+	var first string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: providerConfig + testAccWireGuardKeypairResourceConfig("one"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckWireGuardKeypair("firezone_wireguard_keypair.test", &first),
+					resource.TestCheckResourceAttrPair("firezone_device.test", "public_key", "firezone_wireguard_keypair.test", "public_key"),
+				),
+			},
+			// Unchanged keepers keep the keypair
+			{
+				Config:   providerConfig + testAccWireGuardKeypairResourceConfig("one"),
+				PlanOnly: true,
+			},
+			// Changed keepers rotate the keypair
+			{
+				Config: providerConfig + testAccWireGuardKeypairResourceConfig("two"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					func(s *terraform.State) error {
+						var second string
+						if err := testAccCheckWireGuardKeypair("firezone_wireguard_keypair.test", &second)(s); err != nil {
+							return err
+						}
+						if second == first {
+							return fmt.Errorf("expected a new public key after changing keepers, got %s again", second)
+						}
+						return nil
+					},
+					resource.TestCheckResourceAttrPair("firezone_device.test", "public_key", "firezone_wireguard_keypair.test", "public_key"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckWireGuardKeypair verifies that the public key matches the
+// private key and stores it in publicKey.
+func testAccCheckWireGuardKeypair(name string, publicKey *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("resource %s not found", name)
+		}
+
+		derived, err := wireGuardPublicKey(rs.Primary.Attributes["private_key"])
+		if err != nil {
+			return err
+		}
+
+		if derived != rs.Primary.Attributes["public_key"] {
+			return fmt.Errorf("public key %s does not match private key, expected %s", rs.Primary.Attributes["public_key"], derived)
+		}
+
+		*publicKey = derived
+		return nil
+	}
+}
+
+func TestWireGuardPublicKey(t *testing.T) {
+	// Test vector from RFC 7748, section 6.1.
+	publicKey, err := wireGuardPublicKey("dwdtCnMYpX08FsFyUbJmRd9ML4frwJkqsXf7pR25LCo=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTmo="; publicKey != expected {
+		t.Fatalf("expected %s, got %s", expected, publicKey)
+	}
+}
+
+func testAccWireGuardKeypairResourceConfig(keeper string) string {
+	return fmt.Sprintf(`
+resource "firezone_wireguard_keypair" "test" {
+  keepers = {
+    rotation = %[1]q
+  }
+}
+
+resource "firezone_user" "test" {
+  email = "keypair@example.com"
+  role  = "unprivileged"
+}
+
+resource "firezone_device" "test" {
+  user_id    = firezone_user.test.id
+  name       = "generated"
+  public_key = firezone_wireguard_keypair.test.public_key
+}
+`, keeper)
+}